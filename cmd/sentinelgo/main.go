@@ -12,10 +12,12 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"sentinelgo/internal/config"
+	"sentinelgo/internal/fim"
 	"sentinelgo/internal/heartbeat"
 	"sentinelgo/internal/lockfile"
 	"sentinelgo/internal/osinfo"
@@ -44,9 +46,9 @@ func (p *program) Start(s service.Service) error {
 		fmt.Printf("Warning: failed to log start message: %v\n", err)
 	}
 
-	// Acquire process lock to prevent multiple instances
+	// Acquire the machine-wide lock so only one instance of any version runs
 	version := getCurrentVersion()
-	p.lockFile = lockfile.NewLockFile(fmt.Sprintf("sentinelgo-%s", version))
+	p.lockFile = lockfile.NewGlobalLock()
 
 	// Check for existing lock
 	locked, err := p.lockFile.CheckExistingLock()
@@ -57,7 +59,7 @@ func (p *program) Start(s service.Service) error {
 		return err
 	}
 	if locked {
-		errMsg := fmt.Sprintf("Another instance of SentinelGo v%s is already running", version)
+		errMsg := "Another instance of SentinelGo is already running" + describeLockHolder()
 		if err := logger.Error(errMsg); err != nil {
 			fmt.Printf("Warning: failed to log error: %v\n", err)
 		}
@@ -72,8 +74,11 @@ func (p *program) Start(s service.Service) error {
 		}
 		return errors.New(errMsg)
 	}
+	if err := p.lockFile.WriteMetadata(version, "service"); err != nil {
+		fmt.Printf("Warning: failed to write lock metadata: %v\n", err)
+	}
 
-	if err := logger.Infof("Acquired process lock for SentinelGo v%s", version); err != nil {
+	if err := logger.Infof("Acquired process lock %s for SentinelGo v%s", p.lockFile.Path(), version); err != nil {
 		fmt.Printf("Warning: failed to log info: %v\n", err)
 	}
 
@@ -106,52 +111,189 @@ func (p *program) run() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start auto-updater in background if enabled
-	if p.cfg.AutoUpdate {
-		go updater.AutoUpdateChecker(ctx, p.cfg)
+	// Remove leftovers of an interrupted update before anything else runs
+	updater.CleanupStaleFiles()
+
+	// Background work is tracked so a lock handoff can wait for it to drain
+	var wg sync.WaitGroup
+	spawn := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f()
+		}()
 	}
 
+	// Sample CPU times in the background so heartbeats report interval averages
+	spawn(func() { osinfo.StartCPUSampler(ctx) })
+
+	// All update checks go through the scheduler, which honours update_mode
+	spawn(func() { updater.NewScheduler(p.cfg).Run(ctx) })
+
+	// File integrity changes are reported as events as soon as they are seen
+	monitor := fim.NewMonitor(p.cfg, func(ctx context.Context, c fim.Change) error {
+		return heartbeat.SendEvent(ctx, heartbeat.Event{
+			DeviceID:  p.cfg.DeviceID,
+			Type:      heartbeat.EventFileChanged,
+			Timestamp: c.Time,
+			Details: map[string]any{
+				"path":   c.Path,
+				"change": c.Change,
+				"old":    c.Old,
+				"new":    c.New,
+			},
+		})
+	})
+	spawn(func() { monitor.Run(ctx) })
+
+	// A newer instance may ask for the lock (e.g. after an update)
+	handoff := make(chan lockfile.HandoffRequest, 1)
+	if p.lockFile != nil {
+		spawn(func() {
+			p.lockFile.WatchHandoff(ctx, func(req lockfile.HandoffRequest) {
+				handoff <- req
+			})
+		})
+	}
+
+	// A config copied from another machine must not keep its DeviceID
+	p.bindDeviceID()
+
 	ticker := time.NewTicker(p.cfg.GetHeartbeatInterval())
 	defer ticker.Stop()
 
-	// Initial heartbeat
-	if err := heartbeat.Send(ctx, p.cfg, osinfo.Collect()); err != nil {
+	// Initial heartbeat; the first success confirms a just-applied update
+	healthy := false
+	sysInfo := osinfo.Collect(p.cfg)
+	if err := heartbeat.Send(ctx, p.cfg, sysInfo); err != nil {
 		if err := logger.Errorf("Initial heartbeat failed: %v", err); err != nil {
 			fmt.Printf("Warning: failed to log error: %v\n", err)
 		}
-	}
-
-	// Daily update check (once per day)
-	updateTicker := time.NewTicker(24 * time.Hour)
-	defer updateTicker.Stop()
-
-	// Run update check on start (once)
-	if err := updater.CheckAndApply(ctx, p.cfg); err != nil {
-		if err := logger.Errorf("Update check failed: %v", err); err != nil {
-			fmt.Printf("Warning: failed to log error: %v\n", err)
-		}
+	} else {
+		osinfo.Delivered(p.cfg, sysInfo)
+		healthy = true
+		updater.ConfirmHealthy(ctx, p.cfg)
+		p.reportDeviceIDRotation(ctx)
 	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case req := <-handoff:
+			// Stop all work and wait for it to drain, then let the requester
+			// take the lock. Heartbeats run on this goroutine, so none is in flight.
+			if err := logger.Infof("Handing over to SentinelGo %s (PID %d)", req.Version, req.PID); err != nil {
+				fmt.Printf("Warning: failed to log info: %v\n", err)
+			}
+			cancel()
+			if !waitDrained(&wg, time.Until(req.Deadline)-handoffReleaseMargin) {
+				fmt.Println("Warning: background work did not stop before the handoff deadline")
+			}
+			if err := p.lockFile.Release(); err != nil {
+				fmt.Printf("Warning: failed to release lock: %v\n", err)
+			}
+			os.Exit(0)
 		case <-ticker.C:
-			if err := heartbeat.Send(ctx, p.cfg, osinfo.Collect()); err != nil {
+			sysInfo := osinfo.Collect(p.cfg)
+			if err := heartbeat.Send(ctx, p.cfg, sysInfo); err != nil {
 				if err := logger.Errorf("Heartbeat failed: %v", err); err != nil {
 					fmt.Printf("Warning: failed to log error: %v\n", err)
 				}
-			}
-		case <-updateTicker.C:
-			if err := updater.CheckAndApply(ctx, p.cfg); err != nil {
-				if err := logger.Errorf("Update check failed: %v", err); err != nil {
-					fmt.Printf("Warning: failed to log error: %v\n", err)
+			} else {
+				osinfo.Delivered(p.cfg, sysInfo)
+				if !healthy {
+					healthy = true
+					updater.ConfirmHealthy(ctx, p.cfg)
 				}
+				p.reportDeviceIDRotation(ctx)
 			}
 		}
 	}
 }
 
+// handoffReleaseMargin is kept between releasing the lock and the handoff
+// deadline, after which the requester kills this process
+const handoffReleaseMargin = 2 * time.Second
+
+// waitDrained waits up to timeout for wg and reports whether it finished
+func waitDrained(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// bindDeviceID binds DeviceID to this machine's hardware identifiers and
+// regenerates it when they no longer match
+func (p *program) bindDeviceID() {
+	oldID, rotated, err := p.cfg.BindHardware(osinfo.HardwareIdentity().Components())
+	if err != nil {
+		if err := logger.Errorf("Failed to save hardware binding: %v", err); err != nil {
+			fmt.Printf("Warning: failed to log error: %v\n", err)
+		}
+	}
+	if rotated {
+		if err := logger.Infof("Hardware changed, replaced device ID %s with %s", oldID, p.cfg.DeviceID); err != nil {
+			fmt.Printf("Warning: failed to log info: %v\n", err)
+		}
+	}
+}
+
+// reportDeviceIDRotation sends the device_id_bound event for a first
+// hardware binding and the device_id_rotated event for a pending rotation.
+// Each is retried after every heartbeat until it is delivered.
+func (p *program) reportDeviceIDRotation(ctx context.Context) {
+	if p.cfg.BindingUnreported {
+		err := heartbeat.SendEvent(ctx, heartbeat.Event{
+			DeviceID: p.cfg.DeviceID,
+			Type:     heartbeat.EventDeviceIDBound,
+			Details: map[string]any{
+				"hardware_fingerprint": osinfo.HardwareIdentity().Fingerprint,
+			},
+		})
+		if err != nil {
+			if err := logger.Errorf("Failed to report device ID binding: %v", err); err != nil {
+				fmt.Printf("Warning: failed to log error: %v\n", err)
+			}
+		} else {
+			p.cfg.BindingUnreported = false
+			if err := p.cfg.Save(); err != nil {
+				fmt.Printf("Warning: failed to save config: %v\n", err)
+			}
+		}
+	}
+
+	if p.cfg.PreviousDeviceID == "" {
+		return
+	}
+	err := heartbeat.SendEvent(ctx, heartbeat.Event{
+		DeviceID: p.cfg.DeviceID,
+		Type:     heartbeat.EventDeviceIDRotated,
+		Details: map[string]any{
+			"old_device_id":        p.cfg.PreviousDeviceID,
+			"hardware_fingerprint": osinfo.HardwareIdentity().Fingerprint,
+		},
+	})
+	if err != nil {
+		if err := logger.Errorf("Failed to report device ID rotation: %v", err); err != nil {
+			fmt.Printf("Warning: failed to log error: %v\n", err)
+		}
+		return
+	}
+	p.cfg.PreviousDeviceID = ""
+	if err := p.cfg.Save(); err != nil {
+		fmt.Printf("Warning: failed to save config: %v\n", err)
+	}
+}
+
 // findSentinelGoProcesses finds all running SentinelGo processes
 func findSentinelGoProcesses() ([]ProcessInfo, error) {
 	var cmd *exec.Cmd
@@ -340,8 +482,41 @@ func stopSentinelGoProcesses() error {
 	return nil
 }
 
+// describeLockHolder returns " (vX, PID n, ...)" for the current holder of
+// the global lock, or "" if it cannot be determined
+func describeLockHolder() string {
+	holder, err := lockfile.GlobalHolder()
+	if err != nil || holder == nil || holder.PID == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (version %s, PID %d, %s mode, since %s)", holder.Version, holder.PID, holder.Mode, holder.StartedAt.Local().Format(time.RFC3339))
+}
+
+// showLockHolder prints the instance recorded in the global lock metadata
+func showLockHolder() {
+	fmt.Println("Instance lock:")
+	holder, err := lockfile.GlobalHolder()
+	switch {
+	case err != nil:
+		fmt.Printf("  Failed to read lock: %v\n", err)
+	case holder == nil:
+		fmt.Println("  Not held - no agent instance is running")
+	case holder.PID == 0:
+		fmt.Println("  Held, but the holder has not recorded its metadata")
+	default:
+		fmt.Printf("  PID:     %d\n", holder.PID)
+		fmt.Printf("  Version: %s\n", holder.Version)
+		fmt.Printf("  Mode:    %s\n", holder.Mode)
+		fmt.Printf("  Started: %s\n", holder.StartedAt.Local().Format(time.RFC3339))
+		fmt.Printf("  Exe:     %s\n", holder.ExePath)
+	}
+	fmt.Println()
+}
+
 // showSentinelGoStatus shows all running SentinelGo processes
 func showSentinelGoStatus() error {
+	showLockHolder()
+
 	processes, err := findSentinelGoProcesses()
 	if err != nil {
 		return err
@@ -392,6 +567,79 @@ func showSentinelGoStatus() error {
 	return nil
 }
 
+// showUpdateHistory prints the update ledger, oldest entry first
+func showUpdateHistory(cfg *config.Config) error {
+	entries, err := updater.ReadHistory(cfg)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No update history recorded")
+		return nil
+	}
+
+	fmt.Printf("%-20s  %-9s  %-7s  %-12s  %-12s  %s\n", "TIME", "EVENT", "RESULT", "FROM", "TO", "DETAILS")
+	for _, e := range entries {
+		result := "ok"
+		details := e.Asset
+		if !e.Success {
+			result = "failed"
+			details = e.Error
+		}
+		if e.SHA256 != "" && e.Success {
+			details = fmt.Sprintf("%s sha256:%s", details, e.SHA256)
+		}
+		fmt.Printf("%-20s  %-9s  %-7s  %-12s  %-12s  %s\n",
+			e.Time.Local().Format("2006-01-02 15:04:05"), e.Event, result, e.FromVersion, e.ToVersion, strings.TrimSpace(details))
+	}
+	return nil
+}
+
+// showUpdatePlan prints what the updater would do right now
+func showUpdatePlan(cfg *config.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	plan, err := updater.Plan(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("Current version:  %s\n", plan.CurrentVersion)
+	fmt.Printf("Latest release:   %s\n", plan.LatestVersion)
+	if plan.PinnedVersion != "" {
+		fmt.Printf("Pinned version:   %s\n", plan.PinnedVersion)
+	}
+	fmt.Printf("Target version:   %s\n", plan.TargetVersion)
+	fmt.Printf("Update mode:      %s\n", plan.Mode)
+
+	if !plan.UpdateAvailable {
+		fmt.Printf("Action:           none (%s)\n", plan.Reason)
+		return nil
+	}
+
+	action := "update"
+	if plan.Rollback {
+		action = "rollback"
+	}
+	if plan.AssetName != "" {
+		fmt.Printf("Asset:            %s (%d bytes)\n", plan.AssetName, plan.AssetSize)
+		fmt.Printf("URL:              %s\n", plan.AssetURL)
+		if plan.AssetSHA256 != "" {
+			fmt.Printf("SHA-256:          %s\n", plan.AssetSHA256)
+		} else {
+			fmt.Println("SHA-256:          not published")
+		}
+	}
+	if plan.Allowed {
+		fmt.Printf("Action:           %s %s -> %s\n", action, plan.CurrentVersion, plan.TargetVersion)
+	} else {
+		fmt.Printf("Action:           blocked (%s)\n", plan.Reason)
+	}
+	return nil
+}
+
 // macOS specific launchd service management
 func createLaunchdPlist() error {
 	// Get current version for the plist
@@ -509,6 +757,9 @@ func main() {
 	stop := flag.Bool("stop", false, "Stop all running SentinelGo processes")
 	enableAutoUpdate := flag.Bool("enable-auto-update", false, "Enable automatic updates")
 	version := flag.Bool("version", false, "Show version information")
+	updateHistory := flag.Bool("update-history", false, "Show the local update history")
+	checkUpdate := flag.Bool("check-update", false, "Report what the updater would do without applying anything")
+	takeover := flag.Bool("takeover", false, "With -run, ask a running instance to hand over the lock instead of exiting")
 	flag.Parse()
 
 	// Handle version flag
@@ -536,6 +787,7 @@ func main() {
 
 		// Enable auto-update
 		cfg.AutoUpdate = true
+		cfg.UpdateMode = string(updater.ModeAuto)
 		if err := cfg.Save(); err != nil {
 			log.Fatalf("Failed to save config: %v", err)
 		}
@@ -558,6 +810,22 @@ func main() {
 		return
 	}
 
+	// Handle update-history command
+	if *updateHistory {
+		if err := showUpdateHistory(cfg); err != nil {
+			log.Fatalf("Failed to read update history: %v", err)
+		}
+		return
+	}
+
+	// Handle check-update command (dry run)
+	if *checkUpdate {
+		if err := showUpdatePlan(cfg); err != nil {
+			log.Fatalf("Failed to check for updates: %v", err)
+		}
+		return
+	}
+
 	// Handle stop command
 	if *stop {
 		if err := stopSentinelGoProcesses(); err != nil {
@@ -586,7 +854,7 @@ func main() {
 	prg := &program{cfg: cfg}
 
 	svcCfg := &service.Config{
-		Name:        "SentinelGo",
+		Name:        config.ServiceName,
 		DisplayName: "SentinelGo Agent",
 		Description: "Cross-platform agent to collect OS info and report heartbeat to Supabase",
 		Arguments:   []string{"-config", cfg.Path},
@@ -667,27 +935,35 @@ func main() {
 
 	if *run {
 		// Run in console/foreground mode
-		// Acquire process lock to prevent multiple instances
+		// Acquire the machine-wide lock so only one instance of any version runs
 		version := getCurrentVersion()
-		lockFile := lockfile.NewLockFile(fmt.Sprintf("sentinelgo-%s", version))
+		lockFile := lockfile.NewGlobalLock()
 
 		// Check for existing lock
 		locked, err := lockFile.CheckExistingLock()
 		if err != nil {
 			log.Printf("Warning: Failed to check existing lock: %v", err)
-		} else if locked {
-			log.Printf("Another instance of SentinelGo v%s is already running", version)
-			fmt.Printf("Error: Another instance of SentinelGo v%s is already running\n", version)
+		} else if locked && !*takeover {
+			holder := describeLockHolder()
+			log.Printf("Another instance of SentinelGo is already running%s", holder)
+			fmt.Printf("Error: Another instance of SentinelGo is already running%s\n", holder)
 			fmt.Println("Use './sentinelgo -stop' to stop the running instance first")
 			return
 		}
 
-		// Try to acquire lock
-		if err := lockFile.TryAcquire(); err != nil {
+		// Try to acquire lock, asking the holder to hand it over if requested
+		acquire := lockFile.TryAcquire
+		if *takeover {
+			acquire = func() error { return lockFile.Takeover(version, lockfile.DefaultHandoffDeadline) }
+		}
+		if err := acquire(); err != nil {
 			log.Printf("Failed to acquire process lock: %v", err)
 			fmt.Printf("Error: Failed to acquire process lock: %v\n", err)
 			return
 		}
+		if err := lockFile.WriteMetadata(version, "console"); err != nil {
+			log.Printf("Warning: Failed to write lock metadata: %v", err)
+		}
 		defer func() {
 			if err := lockFile.Release(); err != nil {
 				log.Printf("Warning: Failed to release lock: %v", err)
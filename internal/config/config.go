@@ -15,14 +15,112 @@ var (
 	Version = "dev"
 )
 
+// ServiceName is the name the agent is registered under with the OS service manager
+const ServiceName = "SentinelGo"
+
 type Config struct {
 	Path              string        `json:"-"` // Path to the config file
 	HeartbeatInterval time.Duration `json:"heartbeat_interval"`
 	GitHubOwner       string        `json:"github_owner"`
 	GitHubRepo        string        `json:"github_repo"`
+	GitHubToken       string        `json:"github_token,omitempty"` // Optional token for higher API rate limits
 	CurrentVersion    string        `json:"current_version"`
 	DeviceID          string        `json:"device_id"`   // persistent unique identifier
 	AutoUpdate        bool          `json:"auto_update"` // Enable automatic updates
+
+	// Hardware holds the hardware identifiers DeviceID is bound to
+	Hardware map[string]string `json:"hardware,omitempty"`
+	// PreviousDeviceID is the ID replaced by BindHardware, kept until the
+	// rotation has been reported
+	PreviousDeviceID string `json:"previous_device_id,omitempty"`
+	// BindingUnreported is set when DeviceID was first bound to hardware and
+	// cleared once the binding has been reported
+	BindingUnreported bool `json:"binding_unreported,omitempty"`
+
+	// UpdateMode is one of "off", "notify", "download" or "auto". When empty,
+	// auto_update decides between "auto" and "off".
+	UpdateMode string `json:"update_mode"`
+	// UpdateCheckInterval is a duration string such as "6h" (default "6h")
+	UpdateCheckInterval string `json:"update_check_interval"`
+
+	// PinnedVersion makes the updater converge to this exact release tag,
+	// downgrading if necessary, instead of following the latest release
+	PinnedVersion string `json:"pinned_version"`
+
+	// PreUpdateHook is a shell command run before an update is applied; a
+	// non-zero exit status vetoes the update
+	PreUpdateHook string `json:"pre_update_hook"`
+	// PostUpdateHook is a shell command run once the new version is healthy
+	PostUpdateHook string `json:"post_update_hook"`
+	// HookTimeout bounds each hook, as a duration string (default "5m")
+	HookTimeout string `json:"hook_timeout"`
+
+	// UpdateBandwidthLimit caps update download speed in bytes per second (0 = unlimited)
+	UpdateBandwidthLimit int64 `json:"update_bandwidth_limit"`
+
+	// EmployeeID is an explicitly assigned employee/asset ID
+	EmployeeID string `json:"employee_id,omitempty"`
+	// EmployeeIDFile is a file (e.g. dropped by MDM) whose first line is the
+	// employee ID; defaults to employee_id next to the config file
+	EmployeeIDFile string `json:"employee_id_file,omitempty"`
+	// EmployeeIDHostnameRegex extracts the ID from the hostname; the first
+	// capture group is used if there is one
+	EmployeeIDHostnameRegex string `json:"employee_id_hostname_regex,omitempty"`
+	// EmployeeIDSources orders the sources tried: config, file, console_user,
+	// hostname_regex, hostname (default: all but console_user, in that order)
+	EmployeeIDSources []string `json:"employee_id_sources,omitempty"`
+
+	// Collectors enables (true) or disables (false) snapshot collectors by name,
+	// overriding each collector's default
+	Collectors map[string]bool `json:"collectors,omitempty"`
+	// CollectorTimeout bounds each collector, as a duration string (default "10s")
+	CollectorTimeout string `json:"collector_timeout,omitempty"`
+	// CollectorTimeouts overrides CollectorTimeout for individual collectors
+	CollectorTimeouts map[string]string `json:"collector_timeouts,omitempty"`
+
+	// ProcessTopN enables the process snapshot with the top N processes by
+	// CPU and by memory (0 = disabled)
+	ProcessTopN int `json:"process_top_n"`
+	// ProcessCmdlineAllow limits reported command lines to processes whose
+	// name matches one of these glob patterns (empty = all)
+	ProcessCmdlineAllow []string `json:"process_cmdline_allow,omitempty"`
+	// ProcessCmdlineDeny never reports command lines of matching processes
+	ProcessCmdlineDeny []string `json:"process_cmdline_deny,omitempty"`
+
+	// SoftwareInventoryInterval is how often the full installed software list
+	// is sent; in between only changes are (duration string, default "24h")
+	SoftwareInventoryInterval string `json:"software_inventory_interval,omitempty"`
+
+	// ListenPortAllowlist lists expected listening ports ("22", "8000-8100",
+	// "udp/53"); a new listener outside it raises an unexpected_listener event.
+	// When empty no events are raised.
+	ListenPortAllowlist []string `json:"listen_port_allowlist,omitempty"`
+
+	// SecurityCheckInterval is how long security posture results are reused
+	// before the checks run again (duration string, default "1h")
+	SecurityCheckInterval string `json:"security_check_interval,omitempty"`
+
+	// FIMPaths lists files, directories or globs whose integrity is monitored;
+	// the agent's binary and config are added automatically. Empty disables FIM.
+	FIMPaths []string `json:"fim_paths,omitempty"`
+	// FIMInterval is the periodic rescan interval (duration string, default "15m")
+	FIMInterval string `json:"fim_interval,omitempty"`
+
+	// RemoteLoginAllowUsers lists users expected to log in remotely; a new
+	// remote session by anyone else raises an unexpected_remote_session event.
+	// When empty no events are raised.
+	RemoteLoginAllowUsers []string `json:"remote_login_allow_users,omitempty"`
+	// LoginHistoryLimit is how many recent wtmp login records are reported
+	// (0 = default of 20, negative = disabled)
+	LoginHistoryLimit int `json:"login_history_limit"`
+
+	// DiskIncludeFSTypes limits the disk inventory to these filesystem types
+	DiskIncludeFSTypes []string `json:"disk_include_fstypes,omitempty"`
+	// DiskExcludeFSTypes replaces the built-in list of pseudo filesystems
+	// (tmpfs, overlay, squashfs, ...) left out of the disk inventory
+	DiskExcludeFSTypes []string `json:"disk_exclude_fstypes,omitempty"`
+	// DiskExcludeMounts leaves out mountpoints at or below these paths
+	DiskExcludeMounts []string `json:"disk_exclude_mounts,omitempty"`
 }
 
 // GetHeartbeatInterval returns the heartbeat interval as time.Duration
@@ -31,6 +129,82 @@ func (c *Config) GetHeartbeatInterval() time.Duration {
 	return duration
 }
 
+// GetUpdateCheckInterval returns the update check interval, or 0 if it is
+// unset or cannot be parsed
+func (c *Config) GetUpdateCheckInterval() time.Duration {
+	if c.UpdateCheckInterval == "" {
+		return 0
+	}
+	duration, err := time.ParseDuration(c.UpdateCheckInterval)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// GetHookTimeout returns the update hook timeout, or 0 if it is unset or
+// cannot be parsed
+func (c *Config) GetHookTimeout() time.Duration {
+	duration, err := time.ParseDuration(c.HookTimeout)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// GetCollectorTimeout returns the timeout for the named collector, or 0 if
+// none is configured or it cannot be parsed
+func (c *Config) GetCollectorTimeout(name string) time.Duration {
+	value := c.CollectorTimeouts[name]
+	if value == "" {
+		value = c.CollectorTimeout
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// GetSoftwareInventoryInterval returns the full software inventory interval,
+// or 0 if it is unset or cannot be parsed
+func (c *Config) GetSoftwareInventoryInterval() time.Duration {
+	duration, err := time.ParseDuration(c.SoftwareInventoryInterval)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// GetSecurityCheckInterval returns the security check interval, or 0 if it
+// is unset or cannot be parsed
+func (c *Config) GetSecurityCheckInterval() time.Duration {
+	duration, err := time.ParseDuration(c.SecurityCheckInterval)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// GetFIMInterval returns the FIM rescan interval, or 0 if it is unset or
+// cannot be parsed
+func (c *Config) GetFIMInterval() time.Duration {
+	duration, err := time.ParseDuration(c.FIMInterval)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// GetEmployeeIDFile returns the employee ID file, defaulting to
+// employee_id next to the config file
+func (c *Config) GetEmployeeIDFile() string {
+	if c.EmployeeIDFile != "" {
+		return c.EmployeeIDFile
+	}
+	return filepath.Join(filepath.Dir(c.Path), "employee_id")
+}
+
 func Load(path string) (*Config, error) {
 	cfg := &Config{
 		Path:              path,
@@ -77,6 +251,53 @@ func Load(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// BindHardware ties DeviceID to the given hardware identifiers. If an
+// identifier readable both now and at binding time has changed, the config
+// was copied from another machine (e.g. a cloned image): a new DeviceID is
+// generated and the old one is returned. Identifiers that were unreadable
+// before (e.g. root-only DMI fields) are added without rotating.
+//
+// A config with no binding at all is adopted as-is, since it cannot tell a
+// clone from a first start; BindingUnreported is set so the binding is
+// reported and the backend can spot one DeviceID bound on several machines.
+func (c *Config) BindHardware(ids map[string]string) (oldID string, rotated bool, err error) {
+	changed := false
+	for key, value := range ids {
+		if value == "" {
+			continue
+		}
+		bound, ok := c.Hardware[key]
+		if ok && bound != value {
+			rotated = true
+		}
+		if !ok || bound != value {
+			changed = true
+		}
+	}
+	if !changed {
+		return "", false, nil
+	}
+
+	if len(c.Hardware) == 0 {
+		c.BindingUnreported = true
+	}
+	if rotated {
+		oldID = c.DeviceID
+		c.DeviceID = generateDeviceID()
+		c.PreviousDeviceID = oldID
+		c.Hardware = nil
+	}
+	if c.Hardware == nil {
+		c.Hardware = map[string]string{}
+	}
+	for key, value := range ids {
+		if value != "" {
+			c.Hardware[key] = value
+		}
+	}
+	return oldID, rotated, c.Save()
+}
+
 func generateDeviceID() string {
 	b := make([]byte, 8)
 	if _, err := rand.Read(b); err != nil {
@@ -0,0 +1,298 @@
+package fim
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"sentinelgo/internal/config"
+)
+
+// Change types
+const (
+	Created     = "created"
+	Modified    = "modified"
+	Deleted     = "deleted"
+	Permissions = "permissions_changed"
+)
+
+const (
+	baselineFileName = "fim-baseline.json"
+	// defaultInterval is the rescan interval when fim_interval is not set
+	defaultInterval = 15 * time.Minute
+	// watchDebounce collects bursts of file system notifications into one rescan
+	watchDebounce = 2 * time.Second
+	// maxPending caps changes kept for redelivery while the sink is failing
+	maxPending = 1000
+)
+
+// Entry is the recorded state of one file
+type Entry struct {
+	SHA256  string    `json:"sha256"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	Owner   string    `json:"owner,omitempty"` // uid:gid where available
+	ModTime time.Time `json:"mtime"`
+}
+
+// Change describes a difference between the baseline and a rescan
+type Change struct {
+	Path   string    `json:"path"`
+	Change string    `json:"change"`
+	Time   time.Time `json:"time"`
+	Old    *Entry    `json:"old,omitempty"`
+	New    *Entry    `json:"new,omitempty"`
+}
+
+// Monitor rescans the configured paths and reports changes to a sink
+type Monitor struct {
+	cfg      *config.Config
+	interval time.Duration
+	sink     func(context.Context, Change) error
+	pending  []Change
+}
+
+// NewMonitor builds a monitor for fim_paths. sink receives each change; a
+// change whose delivery fails is retried after the next scan.
+func NewMonitor(cfg *config.Config, sink func(context.Context, Change) error) *Monitor {
+	interval := cfg.GetFIMInterval()
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Monitor{cfg: cfg, interval: interval, sink: sink}
+}
+
+// patterns returns the configured paths plus the agent's own binary and config
+func (m *Monitor) patterns() []string {
+	patterns := append([]string(nil), m.cfg.FIMPaths...)
+	if exe, err := os.Executable(); err == nil {
+		patterns = append(patterns, exe)
+	}
+	return append(patterns, m.cfg.Path)
+}
+
+// Run scans on every interval and, where supported, whenever a watched path
+// changes. It returns immediately if no fim_paths are configured.
+func (m *Monitor) Run(ctx context.Context) {
+	if len(m.cfg.FIMPaths) == 0 {
+		return
+	}
+	fmt.Printf("File integrity monitoring of %d path(s), rescanning every %v\n", len(m.cfg.FIMPaths), m.interval)
+
+	m.scan(ctx)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	patterns := m.patterns()
+	notify := watch(ctx, patterns, func(path string) bool {
+		return covered(patterns, path) && !m.ownFile(path)
+	})
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.scan(ctx)
+		case <-notify:
+			if debounce == nil {
+				debounce = time.After(watchDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			m.scan(ctx)
+		}
+	}
+}
+
+// scan compares the current state with the baseline, stores the new
+// baseline and delivers the changes. The first scan only records a baseline.
+func (m *Monitor) scan(ctx context.Context) {
+	current := snapshot(m.patterns())
+	// The baseline may itself sit in a monitored directory; recording it
+	// would turn every save into a change
+	delete(current, baselinePath(m.cfg))
+	baseline, err := loadBaseline(m.cfg)
+	if err != nil {
+		fmt.Printf("Warning: ignoring unreadable FIM baseline: %v\n", err)
+	}
+	changes := diff(baseline, current)
+	// Only rewrite the baseline when something changed: it usually lives in
+	// a watched directory (next to the config), and every write triggers a rescan
+	if baseline == nil || len(changes) > 0 {
+		if err := saveBaseline(m.cfg, current); err != nil {
+			fmt.Printf("Warning: failed to save FIM baseline: %v\n", err)
+		}
+	}
+	if baseline == nil {
+		fmt.Printf("Recorded FIM baseline of %d file(s)\n", len(current))
+	} else {
+		m.pending = append(m.pending, changes...)
+	}
+
+	var failed []Change
+	for _, c := range m.pending {
+		if err := m.sink(ctx, c); err != nil {
+			fmt.Printf("Warning: failed to report %s change of %s: %v\n", c.Change, c.Path, err)
+			failed = append(failed, c)
+		}
+	}
+	if len(failed) > maxPending {
+		failed = failed[len(failed)-maxPending:]
+	}
+	m.pending = failed
+}
+
+// ownFile reports whether path is the baseline or its temporary file, whose
+// writes must not trigger a rescan
+func (m *Monitor) ownFile(path string) bool {
+	baseline := baselinePath(m.cfg)
+	return path == baseline || path == baseline+".tmp"
+}
+
+// covered reports whether a file system change at path can affect a
+// snapshot of the patterns: path matches a pattern, or lies directly inside
+// a directory that does
+func covered(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		pattern = filepath.Clean(pattern)
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Dir(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot expands the patterns and records every regular file they match.
+// A pattern naming a directory covers the files directly inside it.
+func snapshot(patterns []string) map[string]Entry {
+	files := map[string]Entry{}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			fmt.Printf("Warning: invalid FIM path %q: %v\n", pattern, err)
+			continue
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				continue
+			}
+			if info.IsDir() {
+				children, _ := filepath.Glob(filepath.Join(match, "*"))
+				for _, child := range children {
+					addFile(files, child)
+				}
+				continue
+			}
+			addFile(files, match)
+		}
+	}
+	return files
+}
+
+func addFile(files map[string]Entry, path string) {
+	if _, ok := files[path]; ok {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		// Unreadable files are still tracked by their metadata
+		sum = ""
+	}
+	files[path] = Entry{
+		SHA256:  sum,
+		Size:    info.Size(),
+		Mode:    info.Mode().String(),
+		Owner:   fileOwner(info),
+		ModTime: info.ModTime().UTC(),
+	}
+}
+
+// diff lists the changes from old to cur, sorted by path
+func diff(old, cur map[string]Entry) []Change {
+	now := time.Now().UTC()
+	var changes []Change
+	for path, n := range cur {
+		n := n
+		o, ok := old[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: path, Change: Created, Time: now, New: &n})
+		case o.SHA256 != n.SHA256 || o.Size != n.Size:
+			changes = append(changes, Change{Path: path, Change: Modified, Time: now, Old: &o, New: &n})
+		case o.Mode != n.Mode || o.Owner != n.Owner:
+			changes = append(changes, Change{Path: path, Change: Permissions, Time: now, Old: &o, New: &n})
+		}
+	}
+	for path, o := range old {
+		o := o
+		if _, ok := cur[path]; !ok {
+			changes = append(changes, Change{Path: path, Change: Deleted, Time: now, Old: &o})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func baselinePath(cfg *config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.Path), baselineFileName)
+}
+
+// loadBaseline returns nil without error when no baseline exists yet
+func loadBaseline(cfg *config.Config) (map[string]Entry, error) {
+	data, err := os.ReadFile(baselinePath(cfg))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var baseline map[string]Entry
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, err
+	}
+	return baseline, nil
+}
+
+func saveBaseline(cfg *config.Config, baseline map[string]Entry) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := baselinePath(cfg)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
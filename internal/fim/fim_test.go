@@ -0,0 +1,95 @@
+package fim
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	mtime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry := Entry{SHA256: "aa", Size: 10, Mode: "-rw-r--r--", Owner: "0:0", ModTime: mtime}
+	with := func(change func(*Entry)) Entry {
+		e := entry
+		change(&e)
+		return e
+	}
+
+	old := map[string]Entry{
+		"/etc/unchanged": entry,
+		"/etc/modified":  entry,
+		"/etc/resized":   entry,
+		"/etc/chmod":     entry,
+		"/etc/chown":     entry,
+		"/etc/touched":   entry,
+		"/etc/removed":   entry,
+	}
+	cur := map[string]Entry{
+		"/etc/unchanged": entry,
+		"/etc/modified":  with(func(e *Entry) { e.SHA256 = "bb" }),
+		"/etc/resized":   with(func(e *Entry) { e.Size = 20 }),
+		"/etc/chmod":     with(func(e *Entry) { e.Mode = "-rwxrwxrwx" }),
+		"/etc/chown":     with(func(e *Entry) { e.Owner = "1000:1000" }),
+		// Only the mtime changed: the content is the same
+		"/etc/touched": with(func(e *Entry) { e.ModTime = mtime.Add(time.Hour) }),
+		"/etc/added":   entry,
+	}
+
+	want := map[string]string{
+		"/etc/added":    Created,
+		"/etc/chmod":    Permissions,
+		"/etc/chown":    Permissions,
+		"/etc/modified": Modified,
+		"/etc/removed":  Deleted,
+		"/etc/resized":  Modified,
+	}
+	changes := diff(old, cur)
+	if len(changes) != len(want) {
+		t.Fatalf("diff() = %+v, want %d changes", changes, len(want))
+	}
+	for i, c := range changes {
+		if i > 0 && changes[i-1].Path >= c.Path {
+			t.Errorf("changes not sorted by path: %s before %s", changes[i-1].Path, c.Path)
+		}
+		if want[c.Path] != c.Change {
+			t.Errorf("%s: change = %q, want %q", c.Path, c.Change, want[c.Path])
+		}
+		if (c.Old == nil) != (c.Change == Created) || (c.New == nil) != (c.Change == Deleted) {
+			t.Errorf("%s: Old = %v, New = %v", c.Path, c.Old, c.New)
+		}
+	}
+}
+
+func TestDiffWithoutBaseline(t *testing.T) {
+	changes := diff(nil, map[string]Entry{"/etc/passwd": {SHA256: "aa"}})
+	if len(changes) != 1 || changes[0].Change != Created {
+		t.Fatalf("diff(nil, ...) = %+v, want one created change", changes)
+	}
+}
+
+func TestCovered(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator), "home", "u", ".sentinelgo")
+	patterns := []string{
+		filepath.Join(dir, "config.json"),
+		filepath.Join(string(filepath.Separator), "etc", "ssh", "*"),
+		filepath.Join(string(filepath.Separator), "etc", "cron.d"),
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(dir, "config.json"), true},
+		{filepath.Join(dir, "listeners-baseline.json"), false},
+		{filepath.Join(dir, "update-history.jsonl"), false},
+		{filepath.Join(string(filepath.Separator), "etc", "ssh", "sshd_config"), true},
+		// Files directly inside a directory matched by a pattern
+		{filepath.Join(string(filepath.Separator), "etc", "ssh", "sshd_config.d", "x.conf"), true},
+		{filepath.Join(string(filepath.Separator), "etc", "cron.d", "backup"), true},
+		{filepath.Join(string(filepath.Separator), "etc", "hosts"), false},
+	}
+	for _, tt := range tests {
+		if got := covered(patterns, tt.path); got != tt.want {
+			t.Errorf("covered(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
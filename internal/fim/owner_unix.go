@@ -0,0 +1,18 @@
+//go:build !windows
+
+package fim
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileOwner returns "uid:gid"
+func fileOwner(info os.FileInfo) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", stat.Uid, stat.Gid)
+}
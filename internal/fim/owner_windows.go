@@ -0,0 +1,8 @@
+package fim
+
+import "os"
+
+// fileOwner is not tracked on Windows; ACL changes are not reported
+func fileOwner(info os.FileInfo) string {
+	return ""
+}
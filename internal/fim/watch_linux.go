@@ -0,0 +1,96 @@
+package fim
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const watchMask = unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM |
+	unix.IN_MOVED_TO | unix.IN_ATTRIB | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF
+
+// watch signals on the returned channel whenever something relevant changes
+// in the directories the patterns live in. Watching directories rather than
+// files catches files replaced by rename, as editors and package managers do;
+// events for other files in those directories, such as the agent's own state
+// next to the config, are dropped. It returns nil (never ready) if inotify is
+// unavailable.
+func watch(ctx context.Context, patterns []string, relevant func(path string) bool) <-chan struct{} {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		fmt.Printf("Warning: inotify unavailable, FIM falls back to periodic scans: %v\n", err)
+		return nil
+	}
+
+	dirs := map[string]bool{}
+	for _, pattern := range patterns {
+		dirs[filepath.Dir(pattern)] = true
+		// A pattern may itself name a directory whose files are covered
+		matches, _ := filepath.Glob(pattern)
+		for _, m := range matches {
+			dirs[filepath.Dir(m)] = true
+			dirs[m] = true
+		}
+	}
+	watched := map[int32]string{}
+	for dir := range dirs {
+		if wd, err := unix.InotifyAddWatch(fd, dir, watchMask|unix.IN_ONLYDIR); err == nil {
+			watched[int32(wd)] = dir
+		}
+	}
+	if len(watched) == 0 {
+		unix.Close(fd)
+		return nil
+	}
+
+	// Wrapping the non-blocking descriptor lets the runtime poller wait on it,
+	// so closing the file on shutdown unblocks the reader
+	file := os.NewFile(uintptr(fd), "inotify")
+	notify := make(chan struct{}, 1)
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return
+			}
+			if !anyRelevant(buf[:n], watched, relevant) {
+				continue
+			}
+			select {
+			case notify <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return notify
+}
+
+// anyRelevant reports whether a buffer of inotify events names a relevant
+// path. A queue overflow lost events, so it always counts.
+func anyRelevant(buf []byte, watched map[int32]string, relevant func(path string) bool) bool {
+	for len(buf) >= unix.SizeofInotifyEvent {
+		wd := int32(binary.NativeEndian.Uint32(buf[0:4]))
+		mask := binary.NativeEndian.Uint32(buf[4:8])
+		nameLen := int(binary.NativeEndian.Uint32(buf[12:16]))
+		if len(buf) < unix.SizeofInotifyEvent+nameLen {
+			return true
+		}
+		name := strings.TrimRight(string(buf[unix.SizeofInotifyEvent:unix.SizeofInotifyEvent+nameLen]), "\x00")
+		buf = buf[unix.SizeofInotifyEvent+nameLen:]
+
+		if mask&unix.IN_Q_OVERFLOW != 0 || relevant(filepath.Join(watched[wd], name)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package fim
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchIgnoresUnrelatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(config, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	patterns := []string{config}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notify := watch(ctx, patterns, func(path string) bool { return covered(patterns, path) })
+	if notify == nil {
+		t.Skip("inotify unavailable")
+	}
+
+	// The agent's own state next to the config
+	if err := os.WriteFile(filepath.Join(dir, "sessions-baseline.json"), []byte("[]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-notify:
+		t.Fatal("write to an unmonitored file triggered a rescan")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(config, []byte(`{"fim_paths":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-notify:
+	case <-time.After(5 * time.Second):
+		t.Fatal("write to the monitored config did not trigger a rescan")
+	}
+}
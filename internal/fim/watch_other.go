@@ -0,0 +1,10 @@
+//go:build !linux
+
+package fim
+
+import "context"
+
+// watch is only implemented with inotify; elsewhere FIM relies on periodic scans
+func watch(ctx context.Context, patterns []string, relevant func(path string) bool) <-chan struct{} {
+	return nil
+}
@@ -0,0 +1,60 @@
+package heartbeat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Event types reported to the events table
+const (
+	EventDeviceIDRotated = "device_id_rotated"
+	EventDeviceIDBound   = "device_id_bound"
+	EventFileChanged     = "file_changed"
+)
+
+// Event is a one-off occurrence on a device, sent outside the heartbeat
+type Event struct {
+	DeviceID  string         `json:"device_id"`
+	Type      string         `json:"event_type"`
+	Timestamp time.Time      `json:"timestamp"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// SendEvent posts an event to Supabase `/rest/v1/events`
+func SendEvent(ctx context.Context, event Event) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", SupabaseURL+"/rest/v1/events", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apikey", SupabaseKey)
+	req.Header.Set("Authorization", "Bearer "+SupabaseKey)
+	req.Header.Set("Prefer", "return=minimal")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("event %s failed with status %d", event.Type, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"sentinelgo/internal/config"
 	"sentinelgo/internal/osinfo"
+	"sentinelgo/internal/updater"
 )
 
 var (
@@ -21,10 +22,21 @@ type Payload struct {
 	DeviceID        string `json:"device_id"`
 	Alive           string `json:"alive"`
 	BSID            string `json:"employee_id"`
+	BSIDSource      string `json:"employee_id_source"`
 	OS              string `json:"os"`
 	Uptime          uint64 `json:"uptime"`
 	UptimeFormatted string `json:"uptime_formatted"`
 	MACAddress      string `json:"mac_address"`
+
+	// HardwareFingerprint changes when the same DeviceID shows up on other hardware
+	HardwareFingerprint string `json:"hardware_fingerprint,omitempty"`
+
+	// SystemInfo is the full host snapshot, including CPU averages over the
+	// interval since the previous heartbeat
+	SystemInfo *osinfo.SystemInfo `json:"system_info"`
+
+	// LastUpdate is the most recent update action from the local ledger
+	LastUpdate *updater.HistoryEntry `json:"last_update,omitempty"`
 }
 
 func init() {
@@ -34,13 +46,17 @@ func init() {
 func Send(ctx context.Context, cfg *config.Config, sysInfo *osinfo.SystemInfo) error {
 
 	payload := Payload{
-		DeviceID:        cfg.DeviceID,
-		Alive:           "true",
-		BSID:            sysInfo.EmployeeId,
-		OS:              sysInfo.OS,
-		Uptime:          sysInfo.Uptime,
-		UptimeFormatted: sysInfo.UptimeFormatted,
-		MACAddress:      sysInfo.MACAddress,
+		DeviceID:            cfg.DeviceID,
+		HardwareFingerprint: sysInfo.Hardware.Fingerprint,
+		Alive:               "true",
+		BSID:                sysInfo.EmployeeId,
+		BSIDSource:          sysInfo.EmployeeIdSource,
+		OS:                  sysInfo.OS,
+		Uptime:              sysInfo.Uptime,
+		UptimeFormatted:     sysInfo.UptimeFormatted,
+		MACAddress:          sysInfo.MACAddress,
+		SystemInfo:          sysInfo,
+		LastUpdate:          updater.LastResult(cfg),
 	}
 
 	body, err := json.Marshal(payload)
@@ -69,5 +85,18 @@ func Send(ctx context.Context, cfg *config.Config, sysInfo *osinfo.SystemInfo) e
 		return fmt.Errorf("heartbeat failed with status %d", resp.StatusCode)
 	}
 
+	// Events raised by collectors follow the heartbeat that carries their
+	// snapshot. Failed ones stay in sysInfo.Events so osinfo.Delivered keeps
+	// them out of the baselines and they are raised again next time.
+	var failed []osinfo.Event
+	for _, e := range sysInfo.Events {
+		err := SendEvent(ctx, Event{DeviceID: cfg.DeviceID, Type: e.Type, Details: e.Details})
+		if err != nil {
+			fmt.Printf("Warning: failed to send %s event: %v\n", e.Type, err)
+			failed = append(failed, e)
+		}
+	}
+	sysInfo.Events = failed
+
 	return nil
 }
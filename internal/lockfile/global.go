@@ -0,0 +1,183 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// GlobalLockName is the name of the single machine-wide instance lock
+const GlobalLockName = "sentinelgo"
+
+// Metadata describes the process holding the global lock. It lives in a
+// JSON file next to the lock, because a locked file cannot be read on every
+// platform and the lock file itself only carries the PID.
+type Metadata struct {
+	PID       int       `json:"pid"`
+	Version   string    `json:"version"`
+	StartedAt time.Time `json:"started_at"`
+	ExePath   string    `json:"exe_path"`
+	Mode      string    `json:"mode"` // "service" or "console"
+}
+
+// SystemLockDir is where root-owned agents keep the global lock
+func SystemLockDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, "SentinelGo")
+	case "darwin":
+		return "/var/run/sentinelgo"
+	default:
+		return "/run/sentinelgo"
+	}
+}
+
+// userLockDir is used when the system directory is not writable
+func userLockDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "/tmp"
+	}
+	return filepath.Join(home, ".sentinelgo")
+}
+
+// userLockGlobs matches the per-user lock of every local account, for a
+// system instance to probe
+func userLockGlobs() []string {
+	name := filepath.Join(".sentinelgo", GlobalLockName+".lock")
+	switch runtime.GOOS {
+	case "windows":
+		drive := os.Getenv("SystemDrive")
+		if drive == "" {
+			drive = "C:"
+		}
+		return []string{filepath.Join(drive+`\`, "Users", "*", name)}
+	case "darwin":
+		return []string{filepath.Join("/Users", "*", name), filepath.Join("/var/root", name)}
+	default:
+		return []string{filepath.Join("/home", "*", name), filepath.Join("/root", name)}
+	}
+}
+
+// NewGlobalLock returns the machine-wide instance lock, shared by every
+// version of the agent. It lives in SystemLockDir when that directory can be
+// created (root, or Windows), otherwise in the user's ~/.sentinelgo. Each
+// side probes the other after locking its own file: a user instance checks
+// the system lock, and a system instance checks every user's lock, so a
+// console instance and the service never run side by side.
+func NewGlobalLock() *LockFile {
+	system := SystemLockDir()
+	if err := os.MkdirAll(system, 0755); err == nil && dirWritable(system) {
+		return &LockFile{
+			path:  filepath.Join(system, GlobalLockName+".lock"),
+			probe: userLockGlobs(),
+		}
+	}
+
+	user := userLockDir()
+	if err := os.MkdirAll(user, 0755); err != nil {
+		// The lock file creation will fail later if needed
+		_ = err
+	}
+	return &LockFile{
+		path:  filepath.Join(user, GlobalLockName+".lock"),
+		probe: []string{filepath.Join(system, GlobalLockName+".lock")},
+	}
+}
+
+// dirWritable reports whether files can be created in dir
+func dirWritable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".probe-*")
+	if err != nil {
+		return false
+	}
+	name := f.Name()
+	f.Close()
+	os.Remove(name)
+	return true
+}
+
+// Path returns the lock file location
+func (lf *LockFile) Path() string {
+	return lf.path
+}
+
+func metadataPath(lockPath string) string {
+	return lockPath + ".json"
+}
+
+// WriteMetadata records who holds the lock. It must be called after a
+// successful TryAcquire.
+func (lf *LockFile) WriteMetadata(version, mode string) error {
+	if !lf.acquired {
+		return fmt.Errorf("lock not held")
+	}
+	exe, _ := os.Executable()
+	data, err := json.MarshalIndent(Metadata{
+		PID:       os.Getpid(),
+		Version:   version,
+		StartedAt: time.Now().UTC(),
+		ExePath:   exe,
+		Mode:      mode,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := metadataPath(lf.path)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write lock metadata: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadMetadata returns the metadata of the current holder of this lock, or
+// nil if the lock is free.
+func (lf *LockFile) ReadMetadata() (*Metadata, error) {
+	held, err := isLocked(lf.path)
+	if err != nil || !held {
+		return nil, err
+	}
+	return readMetadata(lf.path)
+}
+
+func readMetadata(lockPath string) (*Metadata, error) {
+	data, err := os.ReadFile(metadataPath(lockPath))
+	if err != nil {
+		return nil, err
+	}
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("parse lock metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// GlobalHolder returns the metadata of whichever process holds the global
+// lock, looking in the system directory first, then in every user's, or nil
+// if no instance runs.
+func GlobalHolder() (*Metadata, error) {
+	paths := []string{filepath.Join(SystemLockDir(), GlobalLockName+".lock")}
+	paths = append(paths, expandProbes(append([]string{filepath.Join(userLockDir(), GlobalLockName+".lock")}, userLockGlobs()...))...)
+	for _, path := range paths {
+		held, err := isLocked(path)
+		if err != nil || !held {
+			continue
+		}
+		meta, err := readMetadata(path)
+		if err != nil {
+			// Held, but by an instance that has not written metadata yet
+			return &Metadata{}, nil
+		}
+		return meta, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,108 @@
+package lockfile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultHandoffDeadline is how long a holder gets to drain and release the
+// lock after a takeover request before it is killed
+const DefaultHandoffDeadline = 30 * time.Second
+
+// handoffPollInterval is how often the holder checks for takeover requests
+const handoffPollInterval = 1 * time.Second
+
+// HandoffRequest is written next to the lock by a process that wants it
+type HandoffRequest struct {
+	PID         int       `json:"pid"`
+	Version     string    `json:"version"`
+	RequestedAt time.Time `json:"requested_at"`
+	Deadline    time.Time `json:"deadline"`
+}
+
+func handoffPath(lockPath string) string {
+	return lockPath + ".handoff"
+}
+
+// Takeover acquires the lock, asking the current holder to hand it over if
+// necessary. The holder is notified through a request file, is expected to
+// drain and release within deadline, and is killed if it does not.
+func (lf *LockFile) Takeover(version string, deadline time.Duration) error {
+	err := lf.TryAcquire()
+	if err == nil || !errors.Is(err, ErrLockHeld) {
+		return err
+	}
+
+	holder, _ := readMetadata(lf.path)
+	req := HandoffRequest{
+		PID:         os.Getpid(),
+		Version:     version,
+		RequestedAt: time.Now().UTC(),
+		Deadline:    time.Now().UTC().Add(deadline),
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(handoffPath(lf.path), data, 0644); err != nil {
+		return fmt.Errorf("write handoff request: %w", err)
+	}
+	defer os.Remove(handoffPath(lf.path))
+
+	fmt.Printf("Requested lock handoff from the running instance, waiting up to %v\n", deadline)
+	if err := lf.AcquireWithTimeout(deadline); err == nil {
+		fmt.Println("Lock handed over")
+		return nil
+	}
+
+	// The holder did not release in time
+	if holder == nil || holder.PID == 0 {
+		return fmt.Errorf("holder did not release the lock within %v and its PID is unknown", deadline)
+	}
+	fmt.Printf("Holder PID %d did not release the lock within %v, killing it\n", holder.PID, deadline)
+	if proc, err := os.FindProcess(holder.PID); err == nil {
+		if err := proc.Kill(); err != nil {
+			fmt.Printf("Warning: failed to kill PID %d: %v\n", holder.PID, err)
+		}
+	}
+	// The kernel drops the lock as soon as the holder is gone
+	if err := lf.AcquireWithTimeout(5 * time.Second); err != nil {
+		return fmt.Errorf("take over lock from PID %d: %w", holder.PID, err)
+	}
+	return nil
+}
+
+// WatchHandoff polls for takeover requests while the lock is held and calls
+// onRequest once when a current one appears. The callback is expected to
+// drain work and Release the lock before the request's deadline.
+func (lf *LockFile) WatchHandoff(ctx context.Context, onRequest func(HandoffRequest)) {
+	ticker := time.NewTicker(handoffPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			data, err := os.ReadFile(handoffPath(lf.path))
+			if err != nil {
+				continue
+			}
+			var req HandoffRequest
+			if err := json.Unmarshal(data, &req); err != nil || req.PID == os.Getpid() {
+				continue
+			}
+			if time.Now().After(req.Deadline) {
+				// Left behind by a requester that gave up
+				os.Remove(handoffPath(lf.path))
+				continue
+			}
+			onRequest(req)
+			return
+		}
+	}
+}
@@ -1,19 +1,34 @@
 package lockfile
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
 )
 
-// LockFile represents a process lock file
+// ErrLockHeld is returned when another process holds the lock
+var ErrLockHeld = errors.New("lock already held by another process")
+
+const (
+	// lockAttempts is how often a lock that appears held is retried, since
+	// another instance's isLocked probe holds a free lock for a moment
+	lockAttempts = 5
+	// lockRetryDelay separates those attempts
+	lockRetryDelay = 20 * time.Millisecond
+)
+
+// LockFile represents a process lock file.
+//
+// Exclusion is enforced by the kernel (flock on Unix, LockFileEx on Windows)
+// on an open handle, so the lock disappears with the process even if it
+// crashes. The PID written into the file is informational only.
 type LockFile struct {
 	path     string
+	probe    []string // Further lock files (or globs) that must be free, see NewGlobalLock
 	file     *os.File
 	acquired bool
 }
@@ -36,47 +51,62 @@ func NewLockFile(name string) *LockFile {
 	}
 }
 
-// TryAcquire attempts to acquire the lock
+// TryAcquire attempts to acquire the lock without blocking
 func (lf *LockFile) TryAcquire() error {
-	// Check if lock file already exists
-	if _, err := os.Stat(lf.path); err == nil {
-		// Lock file exists, check if process is still running
-		if lf.isProcessRunning() {
-			return fmt.Errorf("lock already held by another process")
-		}
-		// Process is not running, remove stale lock file
-		os.Remove(lf.path)
+	if lf.acquired {
+		return nil
 	}
 
-	// Try to create lock file with exclusive access
-	file, err := os.OpenFile(lf.path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+	// The file is never removed, only locked, so there is no window in which
+	// two processes can each lock a different inode under the same name
+	file, err := os.OpenFile(lf.path, os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
-		if os.IsExist(err) {
-			return fmt.Errorf("lock already held by another process")
-		}
-		return fmt.Errorf("failed to create lock file: %w", err)
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFileRetrying(file); err != nil {
+		file.Close()
+		return err
 	}
 	lf.file = file
 
-	// Write our PID to the lock file
-	pid := os.Getpid()
-	_, err = file.WriteString(strconv.Itoa(pid) + "\n")
-	if err != nil {
+	// The service and console instances must also yield to each other
+	for _, path := range expandProbes(lf.probe) {
+		if path == lf.path {
+			continue
+		}
+		if held, _ := isLocked(path); held {
+			unlockFile(file)
+			file.Close()
+			lf.file = nil
+			return ErrLockHeld
+		}
+	}
+
+	// Record our PID for diagnostics
+	if err := writePID(file); err != nil {
+		unlockFile(file)
 		file.Close()
 		lf.file = nil
-		os.Remove(lf.path)
-		return fmt.Errorf("write PID: %w", err)
+		return err
 	}
 
+	lf.acquired = true
+	return nil
+}
+
+// writePID replaces the file contents with the current PID
+func writePID(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("truncate lock file: %w", err)
+	}
+	if _, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		return fmt.Errorf("write PID: %w", err)
+	}
 	// Sync to ensure PID is written to disk
 	if err := file.Sync(); err != nil {
-		file.Close()
-		lf.file = nil
-		os.Remove(lf.path)
 		return fmt.Errorf("sync file: %w", err)
 	}
-
-	lf.acquired = true
 	return nil
 }
 
@@ -88,7 +118,7 @@ func (lf *LockFile) AcquireWithTimeout(timeout time.Duration) error {
 		if err == nil {
 			return nil
 		}
-		if !strings.Contains(err.Error(), "lock already held") {
+		if !errors.Is(err, ErrLockHeld) {
 			return err
 		}
 		time.Sleep(100 * time.Millisecond)
@@ -102,8 +132,11 @@ func (lf *LockFile) Release() error {
 		return nil
 	}
 
-	// Remove the lock file
-	os.Remove(lf.path)
+	// Clear the PID and metadata so readers do not report a stale holder, then
+	// drop the kernel lock. The lock file itself stays in place for the next holder.
+	lf.file.Truncate(0)
+	os.Remove(metadataPath(lf.path))
+	unlockFile(lf.file)
 
 	// Close the file
 	lf.file.Close()
@@ -113,7 +146,7 @@ func (lf *LockFile) Release() error {
 	return nil
 }
 
-// GetLockedPID returns the PID of the process holding the lock
+// GetLockedPID returns the PID recorded by the process holding the lock
 func (lf *LockFile) GetLockedPID() (int, error) {
 	data, err := os.ReadFile(lf.path)
 	if err != nil {
@@ -121,6 +154,9 @@ func (lf *LockFile) GetLockedPID() (int, error) {
 	}
 
 	pidStr := strings.TrimSpace(string(data))
+	if pidStr == "" {
+		return 0, fmt.Errorf("no PID recorded in lock file")
+	}
 	pid, err := strconv.Atoi(pidStr)
 	if err != nil {
 		return 0, fmt.Errorf("invalid PID in lock file: %s", pidStr)
@@ -129,57 +165,77 @@ func (lf *LockFile) GetLockedPID() (int, error) {
 	return pid, nil
 }
 
-// IsProcessRunning checks if a process with the given PID is running
-func IsProcessRunning(pid int) bool {
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return false
+// CheckExistingLock reports whether another process currently holds the lock.
+// It probes the kernel lock rather than trusting the recorded PID, so a
+// reused PID or a crashed holder cannot produce a false answer.
+func (lf *LockFile) CheckExistingLock() (bool, error) {
+	if lf.acquired {
+		return false, nil
 	}
 
-	if runtime.GOOS == "windows" {
-		// On Windows, we can't use Signal(0) the same way
-		// We'll check if the process exists by trying to signal it
-		return process.Signal(os.Kill) != nil // If we can't kill it, it might be running
+	for _, path := range append([]string{lf.path}, expandProbes(lf.probe)...) {
+		held, err := isLocked(path)
+		if err != nil || held {
+			return held, err
+		}
 	}
-
-	// For Unix systems (Linux/macOS), use Signal(0) to check if process exists
-	// This is a non-lethal signal that just checks if the process is reachable
-	err = process.Signal(syscall.Signal(0))
-	return err == nil
+	return false, nil
 }
 
-// isProcessRunning checks if the process in the lock file is still running
-func (lf *LockFile) isProcessRunning() bool {
-	data, err := os.ReadFile(lf.path)
+// isLocked probes the lock at path without keeping it. The file is opened
+// read-only so an unprivileged process can probe a lock owned by root.
+func isLocked(path string) (bool, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return false
+		if os.IsNotExist(err) {
+			return false, nil // No lock file exists
+		}
+		if os.IsPermission(err) {
+			return false, nil
+		}
+		return false, err
 	}
+	defer file.Close()
 
-	pidStr := strings.TrimSpace(string(data))
-	pid, err := strconv.Atoi(pidStr)
-	if err != nil {
-		return false
+	if err := lockFileRetrying(file); err != nil {
+		if errors.Is(err, ErrLockHeld) {
+			return true, nil
+		}
+		return false, err
 	}
-
-	return IsProcessRunning(pid)
+	unlockFile(file)
+	return false, nil
 }
 
-// CheckExistingLock checks if there's an existing lock and if the process is still running
-func (lf *LockFile) CheckExistingLock() (bool, error) {
-	pid, err := lf.GetLockedPID()
-	if err != nil {
-		if os.IsNotExist(err) {
-			return false, nil // No lock file exists
+// lockFileRetrying is lockFile, retried briefly while the lock is held so a
+// concurrent probe is not mistaken for a running instance
+func lockFileRetrying(file *os.File) error {
+	var err error
+	for attempt := 0; attempt < lockAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(lockRetryDelay)
+		}
+		if err = lockFile(file); !errors.Is(err, ErrLockHeld) {
+			return err
 		}
-		return false, err
 	}
+	return err
+}
 
-	// Check if the process is still running
-	if IsProcessRunning(pid) {
-		return true, nil // Process is still running
+// expandProbes resolves probe globs into the lock files that currently
+// exist, without duplicates. Plain paths that match nothing are dropped too,
+// since a missing lock file cannot be held.
+func expandProbes(patterns []string) []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				paths = append(paths, m)
+			}
+		}
 	}
-
-	// Process is dead, clean up the stale lock file
-	os.Remove(lf.path)
-	return false, nil
+	return paths
 }
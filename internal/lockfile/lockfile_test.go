@@ -0,0 +1,177 @@
+package lockfile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Environment variables steering TestHelperProcess when the test binary is
+// re-executed as a competing process
+const (
+	helperEnv     = "SENTINELGO_LOCK_HELPER"
+	helperLockEnv = "SENTINELGO_LOCK_PATH"
+	helperGoEnv   = "SENTINELGO_LOCK_GO"
+)
+
+// TestHelperProcess is not a real test: it is the body of the child
+// processes started by the tests below
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv(helperEnv)
+	if mode == "" {
+		return
+	}
+	lf := &LockFile{path: os.Getenv(helperLockEnv)}
+
+	switch mode {
+	case "compete":
+		// Wait for the start signal so every child tries at the same moment
+		for {
+			if _, err := os.Stat(os.Getenv(helperGoEnv)); err == nil {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		err := lf.TryAcquire()
+		switch {
+		case err == nil:
+			fmt.Println("won")
+			// Hold the lock until every competitor has tried
+			time.Sleep(2 * time.Second)
+			lf.Release()
+		case errors.Is(err, ErrLockHeld):
+			fmt.Println("lost")
+		default:
+			fmt.Println("error:", err)
+		}
+	case "hold":
+		if err := lf.TryAcquire(); err != nil {
+			fmt.Println("error:", err)
+			os.Exit(1)
+		}
+		fmt.Println("acquired")
+		time.Sleep(time.Minute)
+	case "probe":
+		for {
+			isLocked(lf.path)
+		}
+	}
+	os.Exit(0)
+}
+
+func helperCommand(t *testing.T, mode, lockPath string, extraEnv ...string) *exec.Cmd {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), helperEnv+"="+mode, helperLockEnv+"="+lockPath)
+	cmd.Env = append(cmd.Env, extraEnv...)
+	return cmd
+}
+
+func TestTryAcquireCompetingProcesses(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "race.lock")
+	goFile := filepath.Join(dir, "go")
+
+	const children = 8
+	cmds := make([]*exec.Cmd, children)
+	outputs := make([]*strings.Builder, children)
+	for i := range cmds {
+		outputs[i] = &strings.Builder{}
+		cmds[i] = helperCommand(t, "compete", lockPath, helperGoEnv+"="+goFile)
+		cmds[i].Stdout = outputs[i]
+		if err := cmds[i].Start(); err != nil {
+			t.Fatalf("start child %d: %v", i, err)
+		}
+	}
+
+	// Give the children time to start up, then release them together
+	time.Sleep(500 * time.Millisecond)
+	if err := os.WriteFile(goFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	won, lost := 0, 0
+	for i, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("child %d: %v", i, err)
+		}
+		switch out := strings.TrimSpace(outputs[i].String()); out {
+		case "won":
+			won++
+		case "lost":
+			lost++
+		default:
+			t.Fatalf("child %d: unexpected output %q", i, out)
+		}
+	}
+	if won != 1 || lost != children-1 {
+		t.Fatalf("won = %d, lost = %d; want exactly one winner", won, lost)
+	}
+}
+
+func TestLockFreedWhenHolderKilled(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "killed.lock")
+
+	cmd := helperCommand(t, "hold", lockPath)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cmd.Process.Kill() })
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "acquired" {
+		t.Fatalf("child did not acquire the lock: %q, %v", line, err)
+	}
+
+	lf := &LockFile{path: lockPath}
+	if err := lf.TryAcquire(); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("TryAcquire while held = %v, want ErrLockHeld", err)
+	}
+
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Wait()
+
+	if err := lf.TryAcquire(); err != nil {
+		t.Fatalf("TryAcquire after holder was killed: %v", err)
+	}
+	lf.Release()
+}
+
+func TestProbeDoesNotBlockAcquire(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "test.lock")
+	if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another instance checking for a running agent probes the free lock
+	// continuously, briefly locking it each time
+	prober := helperCommand(t, "probe", lockPath)
+	if err := prober.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		prober.Process.Kill()
+		prober.Wait()
+	}()
+
+	lf := &LockFile{path: lockPath}
+	deadline := time.Now().Add(2 * time.Second)
+	for i := 0; time.Now().Before(deadline); i++ {
+		if err := lf.TryAcquire(); err != nil {
+			t.Fatalf("attempt %d: TryAcquire() = %v while only probed", i, err)
+		}
+		lf.Release()
+	}
+}
@@ -0,0 +1,77 @@
+package osinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"sentinelgo/internal/config"
+)
+
+// keyBaseline is the set of keys (listeners, sessions) in the last delivered
+// snapshot. It is kept in a file next to the config, so whatever appeared
+// while the agent was stopped or restarting is still reported as new.
+type keyBaseline struct {
+	mu       sync.Mutex
+	fileName string
+	loaded   bool
+	keys     map[string]bool // nil until a first snapshot has been delivered
+}
+
+func (b *keyBaseline) path(cfg *config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.Path), b.fileName)
+}
+
+// get returns the baseline, or nil if no snapshot has been delivered yet
+func (b *keyBaseline) get(cfg *config.Config) map[string]bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.loaded {
+		b.loaded = true
+		data, err := os.ReadFile(b.path(cfg))
+		if err != nil {
+			return nil
+		}
+		var keys []string
+		if err := json.Unmarshal(data, &keys); err != nil {
+			fmt.Printf("Warning: ignoring unreadable %s: %v\n", b.fileName, err)
+			return nil
+		}
+		b.keys = map[string]bool{}
+		for _, k := range keys {
+			b.keys[k] = true
+		}
+	}
+	return b.keys
+}
+
+// set makes keys the baseline and saves it
+func (b *keyBaseline) set(cfg *config.Config, keys map[string]bool) {
+	if keys == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.keys, b.loaded = keys, true
+
+	list := make([]string, 0, len(keys))
+	for k := range keys {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	data, err := json.Marshal(list)
+	if err != nil {
+		return
+	}
+	path := b.path(cfg)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to save %s: %v\n", b.fileName, err)
+	}
+}
@@ -0,0 +1,52 @@
+package osinfo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"sentinelgo/internal/config"
+)
+
+func TestDeliveredKeepsUnsentListenersOutOfBaseline(t *testing.T) {
+	cfg := &config.Config{Path: filepath.Join(t.TempDir(), "config.json")}
+	saved := listenerBaseline
+	listenerBaseline = &keyBaseline{fileName: saved.fileName}
+	defer func() { listenerBaseline = saved }()
+
+	info := &SystemInfo{
+		listenerKeys: map[string]bool{"tcp/0.0.0.0:22": true, "tcp/0.0.0.0:4444": true},
+		// The heartbeat could not post this one
+		Events: []Event{{Type: EventUnexpectedListener, key: "tcp/0.0.0.0:4444"}},
+	}
+	Delivered(cfg, info)
+
+	// A fresh baseline reads what was saved next to the config
+	listenerBaseline = &keyBaseline{fileName: saved.fileName}
+	got := listenerBaseline.get(cfg)
+	if !got["tcp/0.0.0.0:22"] || got["tcp/0.0.0.0:4444"] {
+		t.Fatalf("baseline = %v, want only the delivered listener", got)
+	}
+}
+
+func TestDeliveredKeepsUnsentSessionsOutOfBaseline(t *testing.T) {
+	cfg := &config.Config{Path: filepath.Join(t.TempDir(), "config.json")}
+	saved := sessionBaseline
+	sessionBaseline = &keyBaseline{fileName: saved.fileName}
+	defer func() { sessionBaseline = saved }()
+
+	delivered := "alice|pts/0|10.0.0.5|2026-10-18T08:00:00Z"
+	unsent := "mallory|pts/1|203.0.113.7|2026-10-18T09:00:00Z"
+	info := &SystemInfo{
+		sessionKeys: map[string]bool{delivered: true, unsent: true},
+		// The heartbeat could not post this one
+		Events: []Event{{Type: EventUnexpectedRemoteSession, key: unsent}},
+	}
+	Delivered(cfg, info)
+
+	// A fresh baseline reads what was saved next to the config
+	sessionBaseline = &keyBaseline{fileName: saved.fileName}
+	got := sessionBaseline.get(cfg)
+	if !got[delivered] || got[unsent] {
+		t.Fatalf("baseline = %v, want only the delivered session", got)
+	}
+}
@@ -0,0 +1,314 @@
+package osinfo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinelgo/internal/config"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// DefaultCollectorTimeout bounds a collector that has no timeout configured
+// and does not provide its own default through a Timeout() method
+const DefaultCollectorTimeout = 10 * time.Second
+
+// Collector gathers one part of the system snapshot.
+//
+// Collect must not touch the snapshot itself: it returns a function that
+// stores its results, which is only applied if the collector finished within
+// its timeout. A collector that overruns is abandoned, not waited for.
+type Collector interface {
+	Name() string
+	Collect(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error)
+}
+
+// Event is something a collector noticed that should be reported on its own,
+// beyond the snapshot
+type Event struct {
+	Type    string         `json:"event_type"`
+	Details map[string]any `json:"details,omitempty"`
+	// key is the baseline entry that raised the event; it is kept out of the
+	// baseline while the event is undelivered, so it is raised again
+	key string
+}
+
+// collectorFunc adapts a function to the Collector interface
+type collectorFunc struct {
+	name    string
+	fn      func(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error)
+	timeout time.Duration // default timeout, if not DefaultCollectorTimeout
+}
+
+func (c collectorFunc) Name() string { return c.name }
+
+func (c collectorFunc) Timeout() time.Duration { return c.timeout }
+
+func (c collectorFunc) Collect(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	return c.fn(ctx, cfg)
+}
+
+var (
+	collectorsMu sync.Mutex
+	collectors   []registration
+	// inFlight holds collectors abandoned after a timeout whose Collect has
+	// not returned yet; they are skipped rather than started again
+	inFlight = map[string]bool{}
+)
+
+type registration struct {
+	collector Collector
+	enabled   bool // unless overridden by the collectors config
+}
+
+// Register adds a collector. Collectors run in registration order;
+// enabledByDefault applies when the collectors config does not mention it.
+func Register(c Collector, enabledByDefault bool) {
+	collectorsMu.Lock()
+	defer collectorsMu.Unlock()
+	collectors = append(collectors, registration{collector: c, enabled: enabledByDefault})
+}
+
+func init() {
+	Register(collectorFunc{name: "host", fn: collectHost}, true)
+	Register(collectorFunc{name: "cpu", fn: collectCPU}, true)
+	Register(collectorFunc{name: "memory", fn: collectMemory}, true)
+	Register(collectorFunc{name: "disk", fn: collectDisk}, true)
+	Register(collectorFunc{name: "network", fn: collectNet}, true)
+	Register(collectorFunc{name: "hardware", fn: collectHardware}, true)
+	Register(collectorFunc{name: "identity", fn: collectIdentity}, true)
+	Register(collectorFunc{name: "processes", fn: collectProcs}, true)
+	Register(collectorFunc{name: "software", fn: collectSoftware}, true)
+	Register(collectorFunc{name: "listeners", fn: collectListeners}, true)
+	Register(collectorFunc{name: "security", fn: collectSecurity, timeout: 2 * time.Minute}, true)
+	Register(collectorFunc{name: "sessions", fn: collectSessions}, true)
+}
+
+// Collect runs every enabled collector with its own timeout. Collectors that
+// fail or time out are listed in CollectionErrors; the rest of the snapshot
+// is still returned.
+func Collect(cfg *config.Config) *SystemInfo {
+	info := &SystemInfo{
+		Timestamp: time.Now(),
+		Arch:      runtime.GOARCH,
+	}
+
+	collectorsMu.Lock()
+	regs := append([]registration(nil), collectors...)
+	collectorsMu.Unlock()
+
+	for _, r := range regs {
+		name := r.collector.Name()
+		if enabled, ok := cfg.Collectors[name]; ok && !enabled || !ok && !r.enabled {
+			continue
+		}
+		if err := runCollector(r.collector, cfg, info); err != nil {
+			if info.CollectionErrors == nil {
+				info.CollectionErrors = map[string]string{}
+			}
+			info.CollectionErrors[name] = err.Error()
+		}
+	}
+	return info
+}
+
+// runCollector runs c with its configured timeout and applies its results
+func runCollector(c Collector, cfg *config.Config, info *SystemInfo) error {
+	timeout := cfg.GetCollectorTimeout(c.Name())
+	if d, ok := c.(interface{ Timeout() time.Duration }); ok && timeout <= 0 {
+		timeout = d.Timeout()
+	}
+	if timeout <= 0 {
+		timeout = DefaultCollectorTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		apply func(*SystemInfo)
+		err   error
+	}
+	// A collector stuck in the kernel (e.g. on a hung NFS mount) ignores its
+	// context; starting it again each heartbeat would pile up blocked threads
+	name := c.Name()
+	collectorsMu.Lock()
+	if inFlight[name] {
+		collectorsMu.Unlock()
+		return fmt.Errorf("skipped: previous run still in progress")
+	}
+	inFlight[name] = true
+	collectorsMu.Unlock()
+
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			collectorsMu.Lock()
+			delete(inFlight, name)
+			collectorsMu.Unlock()
+		}()
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		apply, err := c.Collect(ctx, cfg)
+		done <- result{apply, err}
+	}()
+
+	select {
+	case r := <-done:
+		// Partial results are kept even when the collector reports an error
+		if r.apply != nil {
+			r.apply(info)
+		}
+		return r.err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %v", timeout)
+	}
+}
+
+// Delivered tells collectors that keep a baseline (such as the software
+// inventory or listening ports) that info has reached the backend, so their
+// next report can be relative to it. Events still left in info.Events were
+// not posted and do not advance the baselines.
+func Delivered(cfg *config.Config, info *SystemInfo) {
+	for _, e := range info.Events {
+		switch e.Type {
+		case EventUnexpectedListener:
+			delete(info.listenerKeys, e.key)
+		case EventUnexpectedRemoteSession:
+			delete(info.sessionKeys, e.key)
+		}
+	}
+	deliveredSoftware(cfg, info.Software)
+	deliveredListeners(cfg, info.listenerKeys)
+	deliveredSessions(cfg, info.sessionKeys)
+}
+
+func collectHost(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	hInfo, err := host.InfoWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Normalize OS name for better readability
+	osName := hInfo.OS
+	switch runtime.GOOS {
+	case "darwin":
+		osName = "macOS"
+	case "linux":
+		osName = "linux"
+	case "windows":
+		osName = "windows"
+	}
+
+	return func(info *SystemInfo) {
+		info.Hostname = hInfo.Hostname
+		info.OS = osName
+		info.Platform = hInfo.Platform
+		info.PlatformVer = hInfo.PlatformVersion
+		info.Uptime = hInfo.Uptime
+		info.UptimeFormatted = formatUptime(hInfo.Uptime)
+	}, nil
+}
+
+func collectCPU(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	stats := sampler.snapshot()
+	stats.Cores = runtime.NumCPU()
+
+	cpuInfo, err := cpu.InfoWithContext(ctx)
+	if len(cpuInfo) > 0 {
+		stats.ModelName = cpuInfo[0].ModelName
+	}
+	return func(info *SystemInfo) { info.CPU = stats }, err
+}
+
+func collectMemory(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	memInfo, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return func(info *SystemInfo) {
+		info.Memory = MemoryInfo{
+			Total: memInfo.Total,
+			Used:  memInfo.Used,
+			Free:  memInfo.Free,
+			Usage: memInfo.UsedPercent,
+		}
+	}, nil
+}
+
+func collectDisk(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	usage, rootErr := disk.UsageWithContext(ctx, "/")
+	mounts, err := collectDisks(ctx, cfg)
+	if err == nil && rootErr != nil {
+		err = fmt.Errorf("usage of /: %w", rootErr)
+	}
+	return func(info *SystemInfo) {
+		if usage != nil {
+			info.Disk = DiskInfo{
+				Total: usage.Total,
+				Used:  usage.Used,
+				Free:  usage.Free,
+			}
+		}
+		info.Disks = mounts
+	}, err
+}
+
+func collectNet(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	interfaces, err := net.InterfacesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gateway, gatewayIface := defaultRoute()
+	defaultIface := interfaceName(interfaces, gatewayIface)
+	network, err := collectNetwork(ctx, interfaces)
+	servers := dnsServers()
+
+	return func(info *SystemInfo) {
+		info.Network = network
+		info.DefaultGateway = gateway
+		info.DefaultInterface = defaultIface
+		info.DNSServers = servers
+		info.MACAddress = getPrimaryMACAddress(network, defaultIface)
+	}, err
+}
+
+func collectHardware(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	id := HardwareIdentity()
+	return func(info *SystemInfo) { info.Hardware = id }, nil
+}
+
+func collectIdentity(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	// Clean up hostname for macOS (remove .local suffix)
+	if runtime.GOOS == "darwin" {
+		hostname = strings.TrimSuffix(hostname, ".local")
+		hostname = strings.TrimSuffix(hostname, ".lan")
+		hostname = strings.TrimSuffix(hostname, ".home")
+	}
+
+	id, source := resolveEmployeeID(cfg, hostname)
+	return func(info *SystemInfo) {
+		info.EmployeeId = id
+		info.EmployeeIdSource = source
+	}, nil
+}
+
+func collectProcs(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	snapshot, err := collectProcesses(ctx, cfg)
+	return func(info *SystemInfo) { info.Processes = snapshot }, err
+}
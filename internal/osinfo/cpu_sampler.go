@@ -0,0 +1,134 @@
+package osinfo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/load"
+)
+
+// cpuSampleInterval is how often the background sampler reads CPU times
+const cpuSampleInterval = 15 * time.Second
+
+// cpuWindow accumulates CPU time deltas for one core (or the total)
+type cpuWindow struct {
+	busy, total, iowait, steal float64
+}
+
+func (w *cpuWindow) add(prev, cur cpu.TimesStat) {
+	dTotal := cur.Total() - prev.Total()
+	dIdle := (cur.Idle - prev.Idle) + (cur.Iowait - prev.Iowait)
+	// Counters can go backwards after suspend or CPU hotplug; drop that slice
+	if dTotal <= 0 || dIdle < 0 {
+		return
+	}
+	w.total += dTotal
+	w.busy += dTotal - dIdle
+	w.iowait += cur.Iowait - prev.Iowait
+	w.steal += cur.Steal - prev.Steal
+}
+
+func percent(part, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return part / total * 100
+}
+
+// cpuSampler keeps rolling CPU usage between collections, so a heartbeat
+// reports the average over its whole interval instead of since the last
+// cpu.Percent call
+type cpuSampler struct {
+	mu      sync.Mutex
+	last    []cpu.TimesStat // per core
+	lastAll []cpu.TimesStat // aggregate
+	cores   []cpuWindow
+	all     cpuWindow
+}
+
+var sampler = &cpuSampler{}
+
+// StartCPUSampler takes a baseline immediately and keeps sampling CPU times
+// until ctx is cancelled. Collect works without it, but then the first
+// collection has to measure over a short blocking interval.
+func StartCPUSampler(ctx context.Context) {
+	sampler.sample()
+
+	ticker := time.NewTicker(cpuSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sampler.sample()
+		}
+	}
+}
+
+// sample folds the times since the previous reading into the windows
+func (s *cpuSampler) sample() {
+	perCore, err := cpu.Times(true)
+	if err != nil {
+		return
+	}
+	all, err := cpu.Times(false)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.lastAll) == 1 && len(all) == 1 {
+		s.all.add(s.lastAll[0], all[0])
+	}
+	if len(s.last) == len(perCore) {
+		if len(s.cores) != len(perCore) {
+			s.cores = make([]cpuWindow, len(perCore))
+		}
+		for i := range perCore {
+			s.cores[i].add(s.last[i], perCore[i])
+		}
+	} else {
+		// Core count changed; restart the per-core windows
+		s.cores = nil
+	}
+	s.last = perCore
+	s.lastAll = all
+}
+
+// snapshot returns usage since the previous snapshot and starts a new window
+func (s *cpuSampler) snapshot() CPUInfo {
+	s.mu.Lock()
+	primed := s.lastAll != nil
+	s.mu.Unlock()
+	if !primed {
+		s.sample()
+		time.Sleep(time.Second)
+	}
+	s.sample()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := CPUInfo{
+		Usage:  percent(s.all.busy, s.all.total),
+		IOWait: percent(s.all.iowait, s.all.total),
+		Steal:  percent(s.all.steal, s.all.total),
+	}
+	for _, w := range s.cores {
+		info.PerCore = append(info.PerCore, percent(w.busy, w.total))
+	}
+	s.all = cpuWindow{}
+	s.cores = make([]cpuWindow, len(s.last))
+
+	if avg, err := load.Avg(); err == nil {
+		info.Load1 = avg.Load1
+		info.Load5 = avg.Load5
+		info.Load15 = avg.Load15
+	}
+	return info
+}
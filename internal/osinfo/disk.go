@@ -0,0 +1,109 @@
+package osinfo
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sentinelgo/internal/config"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// MountInfo describes one mounted filesystem
+type MountInfo struct {
+	Device      string  `json:"device"`
+	Mountpoint  string  `json:"mountpoint"`
+	FSType      string  `json:"fstype"`
+	Total       uint64  `json:"total"`
+	Used        uint64  `json:"used"`
+	Free        uint64  `json:"free"`
+	Usage       float64 `json:"usage_percent"`
+	InodesTotal uint64  `json:"inodes_total"`
+	InodesUsed  uint64  `json:"inodes_used"`
+	InodesFree  uint64  `json:"inodes_free"`
+	InodesUsage float64 `json:"inodes_usage_percent"`
+}
+
+// defaultExcludedFSTypes are pseudo and overlay filesystems skipped unless
+// disk_exclude_fstypes is set explicitly
+var defaultExcludedFSTypes = []string{
+	"tmpfs", "devtmpfs", "devfs", "overlay", "squashfs", "proc", "sysfs",
+	"cgroup", "cgroup2", "pstore", "bpf", "tracefs", "debugfs", "securityfs",
+	"configfs", "fusectl", "mqueue", "hugetlbfs", "autofs", "binfmt_misc",
+	"rpc_pipefs", "nsfs", "ramfs", "efivarfs", "fuse.lxcfs", "fuse.portal",
+	"fuse.gvfsd-fuse", "nullfs",
+}
+
+// collectDisks returns every mounted filesystem that passes the configured
+// fstype and mountpoint filters, sorted by mountpoint
+func collectDisks(ctx context.Context, cfg *config.Config) ([]MountInfo, error) {
+	partitions, err := disk.PartitionsWithContext(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	include := cfg.DiskIncludeFSTypes
+	exclude := cfg.DiskExcludeFSTypes
+	if exclude == nil {
+		exclude = defaultExcludedFSTypes
+	}
+
+	seen := map[string]bool{}
+	var mounts []MountInfo
+	for _, p := range partitions {
+		if seen[p.Mountpoint] {
+			continue
+		}
+		if len(include) > 0 && !containsFold(include, p.Fstype) {
+			continue
+		}
+		if containsFold(exclude, p.Fstype) || mountExcluded(cfg.DiskExcludeMounts, p.Mountpoint) {
+			continue
+		}
+
+		usage, err := disk.UsageWithContext(ctx, p.Mountpoint)
+		if err != nil || usage.Total == 0 {
+			continue
+		}
+		seen[p.Mountpoint] = true
+
+		mounts = append(mounts, MountInfo{
+			Device:      p.Device,
+			Mountpoint:  p.Mountpoint,
+			FSType:      p.Fstype,
+			Total:       usage.Total,
+			Used:        usage.Used,
+			Free:        usage.Free,
+			Usage:       usage.UsedPercent,
+			InodesTotal: usage.InodesTotal,
+			InodesUsed:  usage.InodesUsed,
+			InodesFree:  usage.InodesFree,
+			InodesUsage: usage.InodesUsedPercent,
+		})
+	}
+
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].Mountpoint < mounts[j].Mountpoint })
+	return mounts, ctx.Err()
+}
+
+// mountExcluded reports whether mountpoint is, or lies below, one of prefixes
+func mountExcluded(prefixes []string, mountpoint string) bool {
+	for _, prefix := range prefixes {
+		prefix = filepath.Clean(prefix)
+		if mountpoint == prefix || strings.HasPrefix(mountpoint, strings.TrimSuffix(prefix, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
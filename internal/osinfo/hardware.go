@@ -0,0 +1,80 @@
+package osinfo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+// HardwareID identifies the physical (or virtual) machine independently of
+// the agent's DeviceID, so cloned images and re-imaged devices can be told apart
+type HardwareID struct {
+	MachineID   string `json:"machine_id,omitempty"`
+	ProductUUID string `json:"product_uuid,omitempty"`
+	BoardSerial string `json:"board_serial,omitempty"`
+	// Fingerprint is a SHA-256 over the identifiers above that could be read
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+var (
+	hardwareOnce sync.Once
+	hardware     HardwareID
+)
+
+// HardwareIdentity returns the hardware identifiers of this machine. They are
+// read once per process; they do not change while the machine is running.
+func HardwareIdentity() HardwareID {
+	hardwareOnce.Do(func() {
+		hardware = readHardwareID()
+		hardware.MachineID = cleanIdentifier(hardware.MachineID)
+		hardware.ProductUUID = cleanIdentifier(hardware.ProductUUID)
+		hardware.BoardSerial = cleanIdentifier(hardware.BoardSerial)
+		hardware.Fingerprint = fingerprint(hardware)
+	})
+	return hardware
+}
+
+// fingerprint hashes the identifiers that are present, or returns "" if none are
+func fingerprint(id HardwareID) string {
+	if id.MachineID == "" && id.ProductUUID == "" && id.BoardSerial == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte("machine_id=" + id.MachineID +
+		";product_uuid=" + id.ProductUUID +
+		";board_serial=" + id.BoardSerial))
+	return hex.EncodeToString(sum[:])
+}
+
+// placeholderIdentifiers are values firmware vendors leave in unset fields
+var placeholderIdentifiers = []string{
+	"to be filled by o.e.m.", "default string", "not specified", "not applicable",
+	"none", "n/a", "system serial number", "0", "00000000-0000-0000-0000-000000000000",
+	"ffffffff-ffff-ffff-ffff-ffffffffffff", "03000200-0400-0500-0006-000700080009",
+}
+
+// cleanIdentifier normalises an identifier and blanks out placeholders
+func cleanIdentifier(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, p := range placeholderIdentifiers {
+		if s == p {
+			return ""
+		}
+	}
+	return s
+}
+
+// Components returns the identifiers by name, omitting unreadable ones
+func (id HardwareID) Components() map[string]string {
+	components := map[string]string{}
+	if id.MachineID != "" {
+		components["machine_id"] = id.MachineID
+	}
+	if id.ProductUUID != "" {
+		components["product_uuid"] = id.ProductUUID
+	}
+	if id.BoardSerial != "" {
+		components["board_serial"] = id.BoardSerial
+	}
+	return components
+}
@@ -0,0 +1,30 @@
+package osinfo
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// readHardwareID reads the platform UUID and serial number from the IOKit
+// registry. macOS has no separate machine-id.
+func readHardwareID() HardwareID {
+	out, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+	if err != nil {
+		return HardwareID{}
+	}
+	var id HardwareID
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch strings.Trim(strings.TrimSpace(key), `"`) {
+		case "IOPlatformUUID":
+			id.ProductUUID = value
+		case "IOPlatformSerialNumber":
+			id.BoardSerial = value
+		}
+	}
+	return id
+}
@@ -0,0 +1,27 @@
+package osinfo
+
+import (
+	"os"
+	"strings"
+)
+
+// readHardwareID reads the systemd machine-id and the DMI identifiers; the
+// DMI files are only readable by root
+func readHardwareID() HardwareID {
+	return HardwareID{
+		MachineID:   readFirstFile("/etc/machine-id", "/var/lib/dbus/machine-id"),
+		ProductUUID: readFirstFile("/sys/class/dmi/id/product_uuid"),
+		BoardSerial: readFirstFile("/sys/class/dmi/id/board_serial"),
+	}
+}
+
+func readFirstFile(paths ...string) string {
+	for _, path := range paths {
+		if data, err := os.ReadFile(path); err == nil {
+			if s := strings.TrimSpace(string(data)); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
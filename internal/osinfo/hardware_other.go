@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !windows
+
+package osinfo
+
+func readHardwareID() HardwareID {
+	return HardwareID{}
+}
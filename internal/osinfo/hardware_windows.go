@@ -0,0 +1,30 @@
+package osinfo
+
+import (
+	"os/exec"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// readHardwareID reads the MachineGuid created at install time and the SMBIOS
+// system UUID and baseboard serial
+func readHardwareID() HardwareID {
+	var id HardwareID
+	if k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Cryptography`, registry.QUERY_VALUE|registry.WOW64_64KEY); err == nil {
+		id.MachineID, _, _ = k.GetStringValue("MachineGuid")
+		k.Close()
+	}
+	id.ProductUUID = cimProperty("Win32_ComputerSystemProduct", "UUID")
+	id.BoardSerial = cimProperty("Win32_BaseBoard", "SerialNumber")
+	return id
+}
+
+func cimProperty(class, property string) string {
+	out, err := exec.Command("powershell", "-NoProfile", "-Command",
+		"(Get-CimInstance -ClassName "+class+")."+property).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,96 @@
+package osinfo
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"sentinelgo/internal/config"
+)
+
+// Employee ID sources
+const (
+	EmployeeIDConfig        = "config"
+	EmployeeIDFile          = "file"
+	EmployeeIDConsoleUser   = "console_user"
+	EmployeeIDHostnameRegex = "hostname_regex"
+	EmployeeIDHostname      = "hostname"
+)
+
+// defaultEmployeeIDSources keeps devices without employee_id_sources on the
+// hostname they have always reported; console_user is opt-in because it
+// changes with whoever is logged in (and runs PowerShell on Windows)
+var defaultEmployeeIDSources = []string{
+	EmployeeIDConfig, EmployeeIDFile, EmployeeIDHostnameRegex, EmployeeIDHostname,
+}
+
+// resolveEmployeeID returns the employee/asset ID from the first configured
+// source that yields one, and the name of that source
+func resolveEmployeeID(cfg *config.Config, hostname string) (string, string) {
+	sources := cfg.EmployeeIDSources
+	if len(sources) == 0 {
+		sources = defaultEmployeeIDSources
+	}
+
+	for _, source := range sources {
+		var id string
+		switch source {
+		case EmployeeIDConfig:
+			id = cfg.EmployeeID
+		case EmployeeIDFile:
+			id = employeeIDFromFile(cfg.GetEmployeeIDFile())
+		case EmployeeIDConsoleUser:
+			id = consoleUser()
+		case EmployeeIDHostnameRegex:
+			id = employeeIDFromHostname(cfg.EmployeeIDHostnameRegex, hostname)
+		case EmployeeIDHostname:
+			id = hostname
+		default:
+			fmt.Printf("Warning: unknown employee ID source %q\n", source)
+		}
+		if id = strings.TrimSpace(id); id != "" {
+			return id, source
+		}
+	}
+	return "", ""
+}
+
+// employeeIDFromFile reads the first non-empty line of the file our MDM drops
+func employeeIDFromFile(path string) string {
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// employeeIDFromHostname applies pattern to the hostname and returns the
+// first capture group, or the whole match if the pattern has no groups
+func employeeIDFromHostname(pattern, hostname string) string {
+	if pattern == "" {
+		return ""
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		fmt.Printf("Warning: invalid employee_id_hostname_regex: %v\n", err)
+		return ""
+	}
+	m := re.FindStringSubmatch(hostname)
+	switch {
+	case m == nil:
+		return ""
+	case len(m) > 1:
+		return m[1]
+	default:
+		return m[0]
+	}
+}
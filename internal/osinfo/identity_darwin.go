@@ -0,0 +1,26 @@
+package osinfo
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// consoleUser returns the owner of /dev/console, which is the user logged in
+// at the login window (root when nobody is)
+func consoleUser() string {
+	info, err := os.Stat("/dev/console")
+	if err != nil {
+		return ""
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	u, err := user.LookupId(strconv.FormatUint(uint64(stat.Uid), 10))
+	if err != nil || u.Username == "root" {
+		return ""
+	}
+	return u.Username
+}
@@ -0,0 +1,25 @@
+package osinfo
+
+import (
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// consoleUser returns the user logged in on a local terminal or display,
+// ignoring remote (SSH) sessions
+func consoleUser() string {
+	users, err := host.Users()
+	if err != nil {
+		return ""
+	}
+	for _, u := range users {
+		if u.User == "root" || u.Host != "" && !strings.HasPrefix(u.Host, ":") {
+			continue
+		}
+		if strings.HasPrefix(u.Terminal, "tty") || strings.HasPrefix(u.Terminal, ":") || strings.HasPrefix(u.Terminal, "seat") {
+			return u.User
+		}
+	}
+	return ""
+}
@@ -0,0 +1,12 @@
+package osinfo
+
+import "strings"
+
+// consoleUser returns the user signed in at the console, without the domain
+func consoleUser() string {
+	name := cimProperty("Win32_ComputerSystem", "UserName")
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,178 @@
+package osinfo
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"sentinelgo/internal/config"
+
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// EventUnexpectedListener is raised when a port outside listen_port_allowlist
+// starts listening
+const EventUnexpectedListener = "unexpected_listener"
+
+// Listener is a listening TCP socket or a bound, unconnected UDP socket
+type Listener struct {
+	Protocol string `json:"protocol"` // tcp, tcp6, udp, udp6
+	Address  string `json:"address"`
+	Port     uint32 `json:"port"`
+	PID      int32  `json:"pid,omitempty"`
+	Process  string `json:"process,omitempty"`
+	New      bool   `json:"new"`               // not present in the last delivered snapshot
+	Allowed  *bool  `json:"allowed,omitempty"` // only set when an allowlist is configured
+}
+
+func (l Listener) key() string {
+	return l.Protocol + "/" + l.Address + ":" + strconv.FormatUint(uint64(l.Port), 10)
+}
+
+// listenerBaseline holds the listener keys of the last delivered snapshot
+var listenerBaseline = &keyBaseline{fileName: "listeners-baseline.json"}
+
+// portAllowed reports whether a listener matches one of the allowlist
+// entries: "22", "8000-8100", "udp/53" or "tcp/8000-8100"
+func portAllowed(allowlist []string, protocol string, port uint32) bool {
+	for _, entry := range allowlist {
+		proto, ports, ok := strings.Cut(entry, "/")
+		if !ok {
+			proto, ports = "", entry
+		}
+		if proto != "" && !strings.HasPrefix(protocol, strings.ToLower(proto)) {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(ports, "-")
+		if !isRange {
+			hi = lo
+		}
+		min, err1 := strconv.ParseUint(strings.TrimSpace(lo), 10, 32)
+		max, err2 := strconv.ParseUint(strings.TrimSpace(hi), 10, 32)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if uint64(port) >= min && uint64(port) <= max {
+			return true
+		}
+	}
+	return false
+}
+
+func socketProtocol(c net.ConnectionStat) string {
+	proto := "tcp"
+	if c.Type == syscall.SOCK_DGRAM {
+		proto = "udp"
+	}
+	if c.Family == syscall.AF_INET6 {
+		proto += "6"
+	}
+	return proto
+}
+
+// unconnected reports whether a UDP socket has no peer. gopsutil reports the
+// peer of an unconnected socket as "0.0.0.0"/"::" (Linux) or "" with port 0.
+func unconnected(raddr net.Addr) bool {
+	if raddr.Port != 0 {
+		return false
+	}
+	if raddr.IP == "" || raddr.IP == "*" {
+		return true
+	}
+	addr, err := netip.ParseAddr(raddr.IP)
+	return err == nil && addr.IsUnspecified()
+}
+
+// collectListeners lists listening sockets with their owning processes,
+// marks those not in the last delivered snapshot and raises an event for new
+// ones outside the allowlist
+func collectListeners(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	conns, err := net.ConnectionsWithoutUidsWithContext(ctx, "inet")
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[int32]string{}
+	processName := func(pid int32) string {
+		if pid <= 0 {
+			return ""
+		}
+		if name, ok := names[pid]; ok {
+			return name
+		}
+		var name string
+		if p, err := process.NewProcessWithContext(ctx, pid); err == nil {
+			name, _ = p.NameWithContext(ctx)
+		}
+		names[pid] = name
+		return name
+	}
+
+	seen := map[string]bool{}
+	var listeners []Listener
+	for _, c := range conns {
+		proto := socketProtocol(c)
+		listening := c.Status == "LISTEN" ||
+			strings.HasPrefix(proto, "udp") && unconnected(c.Raddr)
+		if !listening {
+			continue
+		}
+		l := Listener{Protocol: proto, Address: c.Laddr.IP, Port: c.Laddr.Port, PID: c.Pid}
+		if seen[l.key()] {
+			continue
+		}
+		seen[l.key()] = true
+		l.Process = processName(c.Pid)
+		listeners = append(listeners, l)
+	}
+	sort.Slice(listeners, func(i, j int) bool {
+		if listeners[i].Port != listeners[j].Port {
+			return listeners[i].Port < listeners[j].Port
+		}
+		return listeners[i].key() < listeners[j].key()
+	})
+
+	previous := listenerBaseline.get(cfg)
+
+	var events []Event
+	for i := range listeners {
+		l := &listeners[i]
+		// Nothing is new until a first snapshot has been delivered
+		l.New = previous != nil && !previous[l.key()]
+		if len(cfg.ListenPortAllowlist) == 0 {
+			continue
+		}
+		allowed := portAllowed(cfg.ListenPortAllowlist, l.Protocol, l.Port)
+		l.Allowed = &allowed
+		if l.New && !allowed {
+			events = append(events, Event{
+				Type: EventUnexpectedListener,
+				Details: map[string]any{
+					"protocol": l.Protocol,
+					"address":  l.Address,
+					"port":     l.Port,
+					"pid":      l.PID,
+					"process":  l.Process,
+				},
+				key: l.key(),
+			})
+			fmt.Printf("Unexpected listener: %s %s:%d (%s, PID %d)\n", l.Protocol, l.Address, l.Port, l.Process, l.PID)
+		}
+	}
+
+	return func(info *SystemInfo) {
+		info.Listeners = listeners
+		info.Events = append(info.Events, events...)
+		info.listenerKeys = seen
+	}, nil
+}
+
+// deliveredListeners makes the delivered listeners the baseline for New
+func deliveredListeners(cfg *config.Config, keys map[string]bool) {
+	listenerBaseline.set(cfg, keys)
+}
@@ -0,0 +1,220 @@
+package osinfo
+
+import (
+	"bufio"
+	"context"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+type NetInfo struct {
+	Name        string   `json:"name"`
+	BytesSent   uint64   `json:"bytes_sent"`
+	BytesRecv   uint64   `json:"bytes_recv"`
+	PacketsSent uint64   `json:"packets_sent"`
+	PacketsRecv uint64   `json:"packets_recv"`
+	ErrIn       uint64   `json:"err_in"`
+	ErrOut      uint64   `json:"err_out"`
+	DropIn      uint64   `json:"drop_in"`
+	DropOut     uint64   `json:"drop_out"`
+	SendRate    float64  `json:"send_bytes_per_sec"` // since the previous collection
+	RecvRate    float64  `json:"recv_bytes_per_sec"`
+	MACAddr     string   `json:"mac_address"`
+	Up          bool     `json:"up"`
+	MTU         int      `json:"mtu"`
+	IPv4        []string `json:"ipv4,omitempty"`
+	IPv6        []string `json:"ipv6,omitempty"`
+	Virtual     bool     `json:"virtual"`
+	Kind        string   `json:"kind,omitempty"` // e.g. loopback, docker, veth, tun, bridge
+}
+
+// netCounters remembers the previous reading so rates cover the interval
+// between collections
+type netCounters struct {
+	mu   sync.Mutex
+	at   time.Time
+	prev map[string]net.IOCountersStat
+}
+
+var lastNet = &netCounters{}
+
+// rates returns bytes/sec sent and received per interface since the previous
+// call, and stores the new reading. The first call returns no rates.
+func (c *netCounters) rates(counters []net.IOCountersStat) map[string][2]float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(c.at).Seconds()
+	out := map[string][2]float64{}
+	for _, cur := range counters {
+		prev, ok := c.prev[cur.Name]
+		// Counters reset when an interface is recreated
+		if !ok || elapsed <= 0 || cur.BytesSent < prev.BytesSent || cur.BytesRecv < prev.BytesRecv {
+			continue
+		}
+		out[cur.Name] = [2]float64{
+			float64(cur.BytesSent-prev.BytesSent) / elapsed,
+			float64(cur.BytesRecv-prev.BytesRecv) / elapsed,
+		}
+	}
+
+	c.at = now
+	c.prev = map[string]net.IOCountersStat{}
+	for _, cur := range counters {
+		c.prev[cur.Name] = cur
+	}
+	return out
+}
+
+// virtualPrefixes maps interface name prefixes to the kind reported for them
+var virtualPrefixes = []struct{ prefix, kind string }{
+	{"docker", "docker"},
+	{"br-", "docker"},
+	{"veth", "veth"},
+	{"cni", "container"},
+	{"flannel", "container"},
+	{"cali", "container"},
+	{"vxlan", "container"},
+	{"virbr", "bridge"},
+	{"bridge", "bridge"},
+	{"br", "bridge"},
+	{"tun", "tun"},
+	{"tap", "tun"},
+	{"utun", "tun"},
+	{"wg", "vpn"},
+	{"zt", "vpn"},
+	{"tailscale", "vpn"},
+	{"ppp", "vpn"},
+	{"ipsec", "vpn"},
+	{"vmnet", "vm"},
+	{"vboxnet", "vm"},
+	{"vEthernet", "vm"},
+	{"awdl", "virtual"},
+	{"llw", "virtual"},
+	{"anpi", "virtual"},
+	{"gif", "virtual"},
+	{"stf", "virtual"},
+	{"dummy", "virtual"},
+}
+
+// interfaceKind classifies an interface as loopback or one of the virtual
+// kinds; physical interfaces return ""
+func interfaceKind(name string, flags []string) string {
+	for _, f := range flags {
+		if f == "loopback" {
+			return "loopback"
+		}
+	}
+	for _, v := range virtualPrefixes {
+		if strings.HasPrefix(name, v.prefix) {
+			return v.kind
+		}
+	}
+	if isVirtualDevice(name) {
+		return "virtual"
+	}
+	return ""
+}
+
+// collectNetwork merges interface details with I/O counters
+func collectNetwork(ctx context.Context, interfaces []net.InterfaceStat) ([]NetInfo, error) {
+	counters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	rates := lastNet.rates(counters)
+
+	byName := map[string]net.InterfaceStat{}
+	for _, iface := range interfaces {
+		byName[iface.Name] = iface
+	}
+
+	var stats []NetInfo
+	for _, ni := range counters {
+		info := NetInfo{
+			Name:        ni.Name,
+			BytesSent:   ni.BytesSent,
+			BytesRecv:   ni.BytesRecv,
+			PacketsSent: ni.PacketsSent,
+			PacketsRecv: ni.PacketsRecv,
+			ErrIn:       ni.Errin,
+			ErrOut:      ni.Errout,
+			DropIn:      ni.Dropin,
+			DropOut:     ni.Dropout,
+			SendRate:    rates[ni.Name][0],
+			RecvRate:    rates[ni.Name][1],
+		}
+
+		if iface, ok := byName[ni.Name]; ok {
+			info.MACAddr = iface.HardwareAddr
+			info.MTU = iface.MTU
+			for _, f := range iface.Flags {
+				if f == "up" {
+					info.Up = true
+				}
+			}
+			for _, a := range iface.Addrs {
+				prefix, err := netip.ParsePrefix(a.Addr)
+				if err != nil {
+					continue
+				}
+				if prefix.Addr().Is4() {
+					info.IPv4 = append(info.IPv4, prefix.Addr().String())
+				} else {
+					info.IPv6 = append(info.IPv6, prefix.Addr().String())
+				}
+			}
+			info.Kind = interfaceKind(iface.Name, iface.Flags)
+		} else {
+			info.Kind = interfaceKind(ni.Name, nil)
+		}
+		info.Virtual = info.Kind != ""
+
+		stats = append(stats, info)
+	}
+	return stats, nil
+}
+
+// interfaceName resolves an interface given by name or by one of its
+// addresses (as Windows route tables report it) to its name
+func interfaceName(interfaces []net.InterfaceStat, nameOrAddr string) string {
+	if nameOrAddr == "" {
+		return ""
+	}
+	for _, iface := range interfaces {
+		if iface.Name == nameOrAddr {
+			return iface.Name
+		}
+		for _, a := range iface.Addrs {
+			if prefix, err := netip.ParsePrefix(a.Addr); err == nil && prefix.Addr().String() == nameOrAddr {
+				return iface.Name
+			}
+		}
+	}
+	return nameOrAddr
+}
+
+// resolvConfServers reads nameserver lines from a resolv.conf style file
+func resolvConfServers(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
@@ -0,0 +1,55 @@
+package osinfo
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// defaultRoute returns the IPv4 default gateway and its interface as
+// reported by `route -n get default`
+func defaultRoute() (gateway, iface string) {
+	out, err := exec.Command("route", "-n", "get", "default").Output()
+	if err != nil {
+		return "", ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "gateway":
+			gateway = strings.TrimSpace(value)
+		case "interface":
+			iface = strings.TrimSpace(value)
+		}
+	}
+	return gateway, iface
+}
+
+// dnsServers returns the resolvers known to the system configuration, which
+// unlike /etc/resolv.conf includes per-interface and VPN resolvers
+func dnsServers() []string {
+	out, err := exec.Command("scutil", "--dns").Output()
+	if err != nil {
+		return resolvConfServers("/etc/resolv.conf")
+	}
+	seen := map[string]bool{}
+	var servers []string
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || !strings.HasPrefix(strings.TrimSpace(key), "nameserver[") {
+			continue
+		}
+		server := strings.TrimSpace(value)
+		if !seen[server] {
+			seen[server] = true
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+func isVirtualDevice(name string) bool {
+	return false
+}
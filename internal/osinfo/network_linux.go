@@ -0,0 +1,52 @@
+package osinfo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultRoute returns the IPv4 default gateway and the interface it is
+// reached through, read from /proc/net/route
+func defaultRoute() (gateway, iface string) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		// The kernel prints the address in host (little-endian) byte order
+		var ip [4]byte
+		binary.BigEndian.PutUint32(ip[:], binary.LittleEndian.Uint32(raw))
+		return netip.AddrFrom4(ip).String(), fields[0]
+	}
+	return "", ""
+}
+
+func dnsServers() []string {
+	return resolvConfServers("/etc/resolv.conf")
+}
+
+// isVirtualDevice reports interfaces that sysfs lists without a backing device
+func isVirtualDevice(name string) bool {
+	if _, err := os.Stat(filepath.Join("/sys/class/net", name)); err != nil {
+		return false
+	}
+	_, err := os.Stat(filepath.Join("/sys/class/net", name, "device"))
+	return os.IsNotExist(err)
+}
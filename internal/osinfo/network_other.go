@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !windows
+
+package osinfo
+
+func defaultRoute() (gateway, iface string) {
+	return "", ""
+}
+
+func dnsServers() []string {
+	return resolvConfServers("/etc/resolv.conf")
+}
+
+func isVirtualDevice(name string) bool {
+	return false
+}
@@ -0,0 +1,42 @@
+package osinfo
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// defaultRoute returns the IPv4 default gateway and the address of the
+// interface it is reached through, parsed from `route print`
+func defaultRoute() (gateway, iface string) {
+	out, err := exec.Command("route", "print", "-4", "0.0.0.0").Output()
+	if err != nil {
+		return "", ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		// Network Destination, Netmask, Gateway, Interface, Metric
+		fields := strings.Fields(line)
+		if len(fields) == 5 && fields[0] == "0.0.0.0" && fields[1] == "0.0.0.0" {
+			return fields[2], fields[3]
+		}
+	}
+	return "", ""
+}
+
+func dnsServers() []string {
+	out, err := exec.Command("powershell", "-NoProfile", "-Command",
+		"(Get-DnsClientServerAddress).ServerAddresses | Sort-Object -Unique").Output()
+	if err != nil {
+		return nil
+	}
+	var servers []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if s := strings.TrimSpace(line); s != "" {
+			servers = append(servers, s)
+		}
+	}
+	return servers
+}
+
+func isVirtualDevice(name string) bool {
+	return false
+}
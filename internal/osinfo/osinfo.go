@@ -2,38 +2,63 @@ package osinfo
 
 import (
 	"fmt"
-	"runtime"
+	"net"
 	"strings"
 	"time"
-
-	"github.com/shirou/gopsutil/v3/cpu"
-	"github.com/shirou/gopsutil/v3/disk"
-	"github.com/shirou/gopsutil/v3/host"
-	"github.com/shirou/gopsutil/v3/mem"
-	"github.com/shirou/gopsutil/v3/net"
 )
 
 type SystemInfo struct {
-	Timestamp       time.Time  `json:"timestamp"`
-	Hostname        string     `json:"hostname"`
-	OS              string     `json:"os"`
-	Platform        string     `json:"platform"`
-	PlatformVer     string     `json:"platform_version"`
-	Arch            string     `json:"arch"`
-	Uptime          uint64     `json:"uptime"`
-	UptimeFormatted string     `json:"uptime_formatted"`
-	CPU             CPUInfo    `json:"cpu"`
-	Memory          MemoryInfo `json:"memory"`
-	Disk            DiskInfo   `json:"disk"`
-	Network         []NetInfo  `json:"network"`
-	EmployeeId      string     `json:"employee_id"`
-	MACAddress      string     `json:"mac_address"`
+	Timestamp        time.Time        `json:"timestamp"`
+	Hostname         string           `json:"hostname"`
+	OS               string           `json:"os"`
+	Platform         string           `json:"platform"`
+	PlatformVer      string           `json:"platform_version"`
+	Arch             string           `json:"arch"`
+	Uptime           uint64           `json:"uptime"`
+	UptimeFormatted  string           `json:"uptime_formatted"`
+	CPU              CPUInfo          `json:"cpu"`
+	Memory           MemoryInfo       `json:"memory"`
+	Disk             DiskInfo         `json:"disk"`
+	Disks            []MountInfo      `json:"disks"`
+	Network          []NetInfo        `json:"network"`
+	DefaultGateway   string           `json:"default_gateway"`
+	DefaultInterface string           `json:"default_interface"`
+	DNSServers       []string         `json:"dns_servers"`
+	EmployeeId       string           `json:"employee_id"`
+	EmployeeIdSource string           `json:"employee_id_source"`
+	MACAddress       string           `json:"mac_address"`
+	Hardware         HardwareID       `json:"hardware"`
+	Processes        *ProcessSnapshot `json:"processes,omitempty"`
+	Software         *SoftwareReport  `json:"software,omitempty"`
+	Listeners        []Listener       `json:"listeners,omitempty"`
+	Security         []SecurityCheck  `json:"security,omitempty"`
+	Sessions         []Session        `json:"sessions,omitempty"`
+	LoginHistory     []LoginRecord    `json:"login_history,omitempty"`
+
+	// Events are reported separately from the snapshot once it is sent;
+	// heartbeat.Send leaves only those it could not post
+	Events []Event `json:"-"`
+	// listenerKeys become the listener baseline once the snapshot is delivered
+	listenerKeys map[string]bool
+	// sessionKeys become the session baseline once the snapshot is delivered
+	sessionKeys map[string]bool
+
+	// CollectionErrors maps each collector that failed or timed out to the reason
+	CollectionErrors map[string]string `json:"collection_errors,omitempty"`
 }
 
+// CPUInfo usage figures are averages over the interval since the previous
+// collection
 type CPUInfo struct {
-	ModelName string  `json:"model_name"`
-	Cores     int     `json:"cores"`
-	Usage     float64 `json:"usage_percent"`
+	ModelName string    `json:"model_name"`
+	Cores     int       `json:"cores"`
+	Usage     float64   `json:"usage_percent"`
+	PerCore   []float64 `json:"per_core_usage_percent"`
+	IOWait    float64   `json:"iowait_percent"`
+	Steal     float64   `json:"steal_percent"`
+	Load1     float64   `json:"load1"`
+	Load5     float64   `json:"load5"`
+	Load15    float64   `json:"load15"`
 }
 
 type MemoryInfo struct {
@@ -49,13 +74,6 @@ type DiskInfo struct {
 	Free  uint64 `json:"free"`
 }
 
-type NetInfo struct {
-	Name      string `json:"name"`
-	BytesSent uint64 `json:"bytes_sent"`
-	BytesRecv uint64 `json:"bytes_recv"`
-	MACAddr   string `json:"mac_address"`
-}
-
 // formatUptime converts uptime in seconds to human-readable format
 func formatUptime(seconds uint64) string {
 	if seconds == 0 {
@@ -110,100 +128,35 @@ func formatUptime(seconds uint64) string {
 	return strings.Join(parts, " ")
 }
 
-// getPrimaryMACAddress returns the first non-empty MAC address from network interfaces
-func getPrimaryMACAddress(netInterfaces []net.InterfaceStat) string {
-	for _, iface := range netInterfaces {
-		if iface.HardwareAddr != "" && !strings.HasPrefix(iface.HardwareAddr, "00:00:00") {
-			return iface.HardwareAddr
+// getPrimaryMACAddress picks the MAC of the interface that best represents
+// the machine: the default route interface first, then physical over virtual,
+// up over down and globally over locally administered addresses. Ties are
+// broken by name so the choice does not depend on enumeration order.
+func getPrimaryMACAddress(interfaces []NetInfo, defaultIface string) string {
+	best, bestScore := "", -1
+	var bestName string
+	for _, iface := range interfaces {
+		hw, err := net.ParseMAC(iface.MACAddr)
+		if err != nil || len(hw) != 6 || strings.HasPrefix(iface.MACAddr, "00:00:00") {
+			continue
 		}
-	}
-	return ""
-}
-
-func Collect() *SystemInfo {
-	hInfo, _ := host.Info()
-	cpuInfo, _ := cpu.Info()
-	cpuPercent, _ := cpu.Percent(0, false)
-	memInfo, _ := mem.VirtualMemory()
-	diskInfo, _ := disk.Usage("/")
-	netInfo, _ := net.IOCounters(true)
-	netInterfaces, _ := net.Interfaces()
-
-	var cpuModel string
-	if len(cpuInfo) > 0 {
-		cpuModel = cpuInfo[0].ModelName
-	}
-
-	var cpuUsage float64
-	if len(cpuPercent) > 0 {
-		cpuUsage = cpuPercent[0]
-	}
-
-	var netStats []NetInfo
-	for _, ni := range netInfo {
-		// Find MAC address for this interface
-		var macAddr string
-		for _, iface := range netInterfaces {
-			if iface.Name == ni.Name {
-				macAddr = iface.HardwareAddr
-				break
-			}
+		score := 0
+		if iface.Name == defaultIface {
+			score += 8
+		}
+		if !iface.Virtual {
+			score += 4
+		}
+		if iface.Up {
+			score += 2
+		}
+		// Randomised and VPN adapter MACs set the locally administered bit
+		if hw[0]&0x02 == 0 {
+			score++
+		}
+		if score > bestScore || (score == bestScore && iface.Name < bestName) {
+			best, bestScore, bestName = strings.ToLower(hw.String()), score, iface.Name
 		}
-
-		netStats = append(netStats, NetInfo{
-			Name:      ni.Name,
-			BytesSent: ni.BytesSent,
-			BytesRecv: ni.BytesRecv,
-			MACAddr:   macAddr,
-		})
-	}
-
-	// Clean up hostname for macOS (remove .local suffix)
-	hostname := hInfo.Hostname
-	if runtime.GOOS == "darwin" {
-		hostname = strings.TrimSuffix(hostname, ".local")
-		hostname = strings.TrimSuffix(hostname, ".lan")
-		hostname = strings.TrimSuffix(hostname, ".home")
-	}
-
-	// Normalize OS name for better readability
-	osName := hInfo.OS
-	switch runtime.GOOS {
-	case "darwin":
-		osName = "macOS"
-	case "linux":
-		osName = "linux"
-	case "windows":
-		osName = "windows"
-	}
-
-	return &SystemInfo{
-		Timestamp:       time.Now(),
-		Hostname:        hInfo.Hostname,
-		OS:              osName,
-		Platform:        hInfo.Platform,
-		PlatformVer:     hInfo.PlatformVersion,
-		Arch:            runtime.GOARCH,
-		Uptime:          hInfo.Uptime,
-		UptimeFormatted: formatUptime(hInfo.Uptime),
-		CPU: CPUInfo{
-			ModelName: cpuModel,
-			Cores:     runtime.NumCPU(),
-			Usage:     cpuUsage,
-		},
-		Memory: MemoryInfo{
-			Total: memInfo.Total,
-			Used:  memInfo.Used,
-			Free:  memInfo.Free,
-			Usage: memInfo.UsedPercent,
-		},
-		Disk: DiskInfo{
-			Total: diskInfo.Total,
-			Used:  diskInfo.Used,
-			Free:  diskInfo.Free,
-		},
-		Network:    netStats,
-		EmployeeId: hostname,
-		MACAddress: getPrimaryMACAddress(netInterfaces),
 	}
+	return best
 }
@@ -0,0 +1,170 @@
+package osinfo
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinelgo/internal/config"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// maxCmdlineLen caps reported command lines
+const maxCmdlineLen = 256
+
+// ProcessInfo is one entry of the top-N process snapshot
+type ProcessInfo struct {
+	PID        int32   `json:"pid"`
+	Name       string  `json:"name"`
+	User       string  `json:"user,omitempty"`
+	Cmdline    string  `json:"cmdline,omitempty"`
+	CPU        float64 `json:"cpu_percent"` // over the interval since the previous collection
+	RSS        uint64  `json:"rss"`
+	MemPercent float32 `json:"memory_percent"`
+}
+
+// ProcessSnapshot lists the heaviest processes by CPU and by resident memory
+type ProcessSnapshot struct {
+	TopCPU    []ProcessInfo `json:"top_cpu"`
+	TopMemory []ProcessInfo `json:"top_memory"`
+}
+
+// procTimes remembers each process's CPU time at the previous collection so
+// CPU usage covers the whole heartbeat interval
+type procTimes struct {
+	mu   sync.Mutex
+	at   time.Time
+	prev map[int32]procSample
+}
+
+type procSample struct {
+	created int64 // distinguishes reused PIDs
+	cpu     float64
+}
+
+var lastProcs = &procTimes{}
+
+// secretKey matches argument names that commonly carry secrets
+const secretKey = `(?:--?|\b)[\w.-]*(?:pass(?:word|wd)?|secret|token|api[_-]?key|auth|credential|private[_-]?key)[\w.-]*`
+
+var (
+	// secretAssign matches key=value and key:value forms
+	secretAssign = regexp.MustCompile(`(?i)(` + secretKey + `[=:])(\S+)`)
+	// secretFlag matches "--key value", leaving a following flag alone
+	secretFlag = regexp.MustCompile(`(?i)(` + secretKey + `\s+)([^\s-]\S*)`)
+)
+
+// redactCmdline masks likely secrets and truncates the command line
+func redactCmdline(cmdline string) string {
+	cmdline = secretAssign.ReplaceAllString(cmdline, "${1}***")
+	cmdline = secretFlag.ReplaceAllString(cmdline, "${1}***")
+	if len(cmdline) > maxCmdlineLen {
+		cmdline = cmdline[:maxCmdlineLen] + "..."
+	}
+	return cmdline
+}
+
+// matchesAny reports whether name matches one of the glob patterns,
+// case-insensitively
+func matchesAny(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(strings.ToLower(p), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// showCmdline applies the command line allowlist and denylist
+func showCmdline(cfg *config.Config, name string) bool {
+	if matchesAny(cfg.ProcessCmdlineDeny, name) {
+		return false
+	}
+	return len(cfg.ProcessCmdlineAllow) == 0 || matchesAny(cfg.ProcessCmdlineAllow, name)
+}
+
+// collectProcesses returns the top process_top_n processes by CPU and by
+// RSS, or nil when the collector is disabled
+func collectProcesses(ctx context.Context, cfg *config.Config) (*ProcessSnapshot, error) {
+	n := cfg.ProcessTopN
+	if n <= 0 {
+		return nil, nil
+	}
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lastProcs.mu.Lock()
+	now := time.Now()
+	elapsed := now.Sub(lastProcs.at).Seconds()
+	prev := lastProcs.prev
+	current := make(map[int32]procSample, len(procs))
+
+	type candidate struct {
+		proc *process.Process
+		info ProcessInfo
+	}
+	var all []candidate
+	for _, p := range procs {
+		times, err := p.Times()
+		if err != nil {
+			continue
+		}
+		created, _ := p.CreateTime()
+		sample := procSample{created: created, cpu: times.User + times.System}
+		current[p.Pid] = sample
+
+		var cpuPercent float64
+		if old, ok := prev[p.Pid]; ok && old.created == created && elapsed > 0 {
+			cpuPercent = (sample.cpu - old.cpu) / elapsed * 100
+		} else if created > 0 {
+			// New process (or first collection): average over its lifetime
+			if lifetime := now.Sub(time.UnixMilli(created)).Seconds(); lifetime > 0 {
+				cpuPercent = sample.cpu / lifetime * 100
+			}
+		}
+
+		info := ProcessInfo{PID: p.Pid, CPU: cpuPercent}
+		if mem, err := p.MemoryInfo(); err == nil {
+			info.RSS = mem.RSS
+		}
+		all = append(all, candidate{proc: p, info: info})
+	}
+	lastProcs.at = now
+	lastProcs.prev = current
+	lastProcs.mu.Unlock()
+
+	// Names, users and command lines are only looked up for the winners
+	describe := func(c candidate) ProcessInfo {
+		info := c.info
+		info.Name, _ = c.proc.Name()
+		info.User, _ = c.proc.Username()
+		info.MemPercent, _ = c.proc.MemoryPercent()
+		if showCmdline(cfg, info.Name) {
+			if cmdline, err := c.proc.Cmdline(); err == nil {
+				info.Cmdline = redactCmdline(cmdline)
+			}
+		}
+		return info
+	}
+	top := func(less func(a, b ProcessInfo) bool) []ProcessInfo {
+		sort.Slice(all, func(i, j int) bool { return less(all[i].info, all[j].info) })
+		var out []ProcessInfo
+		for i := 0; i < len(all) && i < n; i++ {
+			out = append(out, describe(all[i]))
+		}
+		return out
+	}
+
+	return &ProcessSnapshot{
+		TopCPU:    top(func(a, b ProcessInfo) bool { return a.CPU > b.CPU }),
+		TopMemory: top(func(a, b ProcessInfo) bool { return a.RSS > b.RSS }),
+	}, nil
+}
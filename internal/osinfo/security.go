@@ -0,0 +1,57 @@
+package osinfo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sentinelgo/internal/config"
+)
+
+// Security check outcomes
+const (
+	CheckPass          = "pass"
+	CheckFail          = "fail"
+	CheckUnknown       = "unknown"        // could not be determined, e.g. not root
+	CheckNotApplicable = "not_applicable" // e.g. no SSH server installed
+)
+
+// defaultSecurityCheckInterval is how long security check results are reused
+const defaultSecurityCheckInterval = time.Hour
+
+// SecurityCheck is the result of one host hardening check
+type SecurityCheck struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Evidence string `json:"evidence,omitempty"`
+}
+
+// lastSecurity caches check results; most of them run external tools and
+// the posture rarely changes between heartbeats
+var lastSecurity struct {
+	sync.Mutex
+	at     time.Time
+	checks []SecurityCheck
+}
+
+func collectSecurity(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	interval := cfg.GetSecurityCheckInterval()
+	if interval <= 0 {
+		interval = defaultSecurityCheckInterval
+	}
+
+	lastSecurity.Lock()
+	defer lastSecurity.Unlock()
+	if lastSecurity.checks == nil || time.Since(lastSecurity.at) >= interval {
+		checks := securityChecks(ctx)
+		if ctx.Err() != nil {
+			// Checks cut short by the timeout would all read "unknown"
+			return nil, ctx.Err()
+		}
+		lastSecurity.checks = checks
+		lastSecurity.at = time.Now()
+	}
+
+	checks := lastSecurity.checks
+	return func(info *SystemInfo) { info.Security = checks }, nil
+}
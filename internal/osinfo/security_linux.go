@@ -0,0 +1,414 @@
+package osinfo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// securityChecks evaluates the Linux hardening checks
+func securityChecks(ctx context.Context) []SecurityCheck {
+	checks := []SecurityCheck{
+		checkFirewall(ctx),
+		checkDiskEncryption(ctx),
+		checkAutoUpdates(ctx),
+	}
+	checks = append(checks, checkSSH(ctx)...)
+	checks = append(checks, checkScreenLock(ctx), checkSecurityUpdates(ctx))
+	return checks
+}
+
+// commandOutput runs a tool, reporting found=false when it is not installed
+func commandOutput(ctx context.Context, env []string, name string, args ...string) (out string, found bool, err error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", false, nil
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	data, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+	}
+	return string(data), true, err
+}
+
+// checkFirewall passes when ufw is active or nftables/iptables hold rules
+func checkFirewall(ctx context.Context) SecurityCheck {
+	check := SecurityCheck{Name: "firewall", Status: CheckUnknown}
+	var evidence []string
+	ran := false
+
+	if out, found, err := commandOutput(ctx, nil, "ufw", "status"); found {
+		if err != nil {
+			evidence = append(evidence, "ufw: "+err.Error())
+		} else {
+			ran = true
+			active := strings.Contains(out, "Status: active")
+			evidence = append(evidence, fmt.Sprintf("ufw active: %t", active))
+			if active {
+				check.Status = CheckPass
+			}
+		}
+	}
+
+	if out, found, err := commandOutput(ctx, nil, "nft", "list", "ruleset"); found {
+		if err != nil {
+			evidence = append(evidence, "nftables: "+err.Error())
+		} else {
+			ran = true
+			rules := countNftRules(out)
+			evidence = append(evidence, fmt.Sprintf("nftables rules: %d", rules))
+			if rules > 0 {
+				check.Status = CheckPass
+			}
+		}
+	}
+
+	if out, found, err := commandOutput(ctx, nil, "iptables", "-S"); found {
+		if err != nil {
+			evidence = append(evidence, "iptables: "+err.Error())
+		} else {
+			ran = true
+			rules := 0
+			for _, line := range strings.Split(out, "\n") {
+				if strings.HasPrefix(line, "-A ") {
+					rules++
+				}
+			}
+			evidence = append(evidence, fmt.Sprintf("iptables rules: %d", rules))
+			if rules > 0 {
+				check.Status = CheckPass
+			}
+		}
+	}
+
+	if check.Status != CheckPass && ran {
+		check.Status = CheckFail
+	}
+	if len(evidence) == 0 {
+		evidence = append(evidence, "no firewall tooling found (ufw, nft, iptables)")
+	}
+	check.Evidence = strings.Join(evidence, "; ")
+	return check
+}
+
+// countNftRules counts rule lines inside chains of `nft list ruleset` output
+func countNftRules(ruleset string) int {
+	rules := 0
+	inChain := false
+	for _, line := range strings.Split(ruleset, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "chain "):
+			inChain = true
+		case line == "}":
+			inChain = false
+		case inChain && line != "" && !strings.HasPrefix(line, "type ") && !strings.HasPrefix(line, "policy "):
+			rules++
+		}
+	}
+	return rules
+}
+
+// rootDevice returns the device mounted on /
+func rootDevice() string {
+	f, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var device string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// Later mounts over / shadow earlier ones
+		if len(fields) >= 2 && fields[1] == "/" {
+			device = fields[0]
+		}
+	}
+	return device
+}
+
+// checkDiskEncryption passes when the root filesystem sits on a dm-crypt
+// (LUKS) device
+func checkDiskEncryption(ctx context.Context) SecurityCheck {
+	check := SecurityCheck{Name: "disk_encryption", Status: CheckUnknown}
+	device := rootDevice()
+	if !strings.HasPrefix(device, "/dev/") {
+		check.Evidence = fmt.Sprintf("root filesystem is not on a block device (%q)", device)
+		return check
+	}
+
+	// -s lists the device's ancestors: partition, LVM volume, crypt mapping...
+	out, found, err := commandOutput(ctx, nil, "lsblk", "-s", "-l", "-n", "-o", "NAME,TYPE", device)
+	if !found || err != nil {
+		check.Evidence = fmt.Sprintf("lsblk unavailable for %s: %v", device, err)
+		return check
+	}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == "crypt" {
+			check.Status = CheckPass
+			check.Evidence = fmt.Sprintf("%s is backed by crypt device %s", device, fields[0])
+			return check
+		}
+	}
+	check.Status = CheckFail
+	check.Evidence = fmt.Sprintf("no crypt device under %s", device)
+	return check
+}
+
+// checkAutoUpdates passes when unattended-upgrades or dnf-automatic is enabled
+func checkAutoUpdates(ctx context.Context) SecurityCheck {
+	check := SecurityCheck{Name: "auto_updates", Status: CheckUnknown}
+
+	if _, err := exec.LookPath("apt-get"); err == nil {
+		check.Status = CheckFail
+		check.Evidence = "APT::Periodic::Unattended-Upgrade is not enabled"
+		if _, err := exec.LookPath("unattended-upgrade"); err != nil {
+			check.Evidence = "unattended-upgrades is not installed"
+			return check
+		}
+		files, _ := filepath.Glob("/etc/apt/apt.conf.d/*")
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			for _, line := range strings.Split(string(data), "\n") {
+				line = strings.TrimSpace(line)
+				if strings.HasPrefix(line, "APT::Periodic::Unattended-Upgrade") {
+					value := strings.Trim(strings.TrimPrefix(line, "APT::Periodic::Unattended-Upgrade"), ` ";`)
+					if value != "" && value != "0" {
+						check.Status = CheckPass
+						check.Evidence = fmt.Sprintf("%s: %s", file, line)
+					} else {
+						check.Status = CheckFail
+						check.Evidence = fmt.Sprintf("%s: %s", file, line)
+					}
+				}
+			}
+		}
+		return check
+	}
+
+	for _, timer := range []string{"dnf-automatic-install.timer", "dnf-automatic.timer"} {
+		out, found, _ := commandOutput(ctx, nil, "systemctl", "is-enabled", timer)
+		if !found {
+			break
+		}
+		if strings.TrimSpace(out) == "enabled" {
+			check.Status = CheckPass
+			check.Evidence = timer + " is enabled"
+			return check
+		}
+	}
+	if _, err := exec.LookPath("dnf"); err == nil {
+		check.Status = CheckFail
+		check.Evidence = "dnf-automatic timer is not enabled"
+		return check
+	}
+
+	check.Evidence = "no supported package manager (apt, dnf) found"
+	return check
+}
+
+// sshdSettings returns the effective sshd settings (lowercase keys), from
+// `sshd -T` when permitted, otherwise from the config files. found is false
+// when no SSH server is installed.
+func sshdSettings(ctx context.Context) (settings map[string]string, source string, found bool) {
+	if out, ok, err := commandOutput(ctx, nil, "sshd", "-T"); ok && err == nil {
+		settings = map[string]string{}
+		for _, line := range strings.Split(out, "\n") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+				settings[strings.ToLower(key)] = value
+			}
+		}
+		return settings, "sshd -T", true
+	}
+
+	if _, err := os.Stat("/etc/ssh/sshd_config"); err != nil {
+		_, lookErr := exec.LookPath("sshd")
+		return nil, "", lookErr == nil
+	}
+	settings = map[string]string{}
+	parseSSHDConfig("/etc/ssh/sshd_config", settings, 0)
+	return settings, "/etc/ssh/sshd_config", true
+}
+
+// parseSSHDConfig reads sshd_config and its Includes. As in sshd, the first
+// value obtained for a keyword wins; Match blocks are ignored.
+func parseSSHDConfig(path string, settings map[string]string, depth int) {
+	if depth > 8 {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		key := strings.ToLower(fields[0])
+		switch key {
+		case "match":
+			return
+		case "include":
+			for _, pattern := range fields[1:] {
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join("/etc/ssh", pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				for _, m := range matches {
+					parseSSHDConfig(m, settings, depth+1)
+				}
+			}
+		default:
+			if _, ok := settings[key]; !ok {
+				settings[key] = strings.ToLower(fields[1])
+			}
+		}
+	}
+}
+
+// checkSSH reports PermitRootLogin and PasswordAuthentication
+func checkSSH(ctx context.Context) []SecurityCheck {
+	root := SecurityCheck{Name: "ssh_permit_root_login"}
+	password := SecurityCheck{Name: "ssh_password_authentication"}
+
+	settings, source, found := sshdSettings(ctx)
+	if !found {
+		root.Status, password.Status = CheckNotApplicable, CheckNotApplicable
+		root.Evidence, password.Evidence = "no SSH server installed", "no SSH server installed"
+		return []SecurityCheck{root, password}
+	}
+
+	// Defaults of current OpenSSH releases when the keyword is absent
+	permitRoot := settings["permitrootlogin"]
+	if permitRoot == "" {
+		permitRoot = "prohibit-password"
+	}
+	switch permitRoot {
+	case "no", "prohibit-password", "without-password", "forced-commands-only":
+		root.Status = CheckPass
+	default:
+		root.Status = CheckFail
+	}
+	root.Evidence = fmt.Sprintf("PermitRootLogin %s (%s)", permitRoot, source)
+
+	passwordAuth := settings["passwordauthentication"]
+	if passwordAuth == "" {
+		passwordAuth = "yes"
+	}
+	password.Status = CheckFail
+	if passwordAuth == "no" {
+		password.Status = CheckPass
+	}
+	password.Evidence = fmt.Sprintf("PasswordAuthentication %s (%s)", passwordAuth, source)
+
+	return []SecurityCheck{root, password}
+}
+
+// checkScreenLock reads the console user's GNOME screensaver settings
+func checkScreenLock(ctx context.Context) SecurityCheck {
+	check := SecurityCheck{Name: "screen_lock", Status: CheckNotApplicable}
+	name := consoleUser()
+	if name == "" {
+		check.Evidence = "no local console session"
+		return check
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		check.Status = CheckUnknown
+		check.Evidence = fmt.Sprintf("look up %s: %v", name, err)
+		return check
+	}
+
+	// gsettings reads the user's dconf database directly through HOME
+	env := []string{"HOME=" + u.HomeDir, "XDG_CONFIG_HOME=" + filepath.Join(u.HomeDir, ".config")}
+	lock, found, err := commandOutput(ctx, env, "gsettings", "get", "org.gnome.desktop.screensaver", "lock-enabled")
+	if !found {
+		check.Status = CheckUnknown
+		check.Evidence = "gsettings not available; only GNOME is supported"
+		return check
+	}
+	if err != nil {
+		check.Status = CheckUnknown
+		check.Evidence = err.Error()
+		return check
+	}
+	idle, _, _ := commandOutput(ctx, env, "gsettings", "get", "org.gnome.desktop.session", "idle-delay")
+
+	lock = strings.TrimSpace(lock)
+	idle = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(idle), "uint32"))
+	check.Evidence = fmt.Sprintf("user %s: lock-enabled %s, idle-delay %s", name, lock, idle)
+	check.Status = CheckFail
+	if lock == "true" && idle != "0" {
+		check.Status = CheckPass
+	}
+	return check
+}
+
+// checkSecurityUpdates counts pending security updates from the package
+// manager's cached metadata
+func checkSecurityUpdates(ctx context.Context) SecurityCheck {
+	check := SecurityCheck{Name: "pending_security_updates", Status: CheckUnknown}
+
+	if out, found, err := commandOutput(ctx, nil, "apt-get", "-s", "-o", "Debug::NoLocking=1", "upgrade"); found {
+		if err != nil {
+			check.Evidence = "apt-get: " + err.Error()
+			return check
+		}
+		count := 0
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, "Inst ") && strings.Contains(strings.ToLower(line), "security") {
+				count++
+			}
+		}
+		return securityUpdatesResult(check, count, "apt")
+	}
+
+	if out, found, err := commandOutput(ctx, nil, "dnf", "-q", "-C", "updateinfo", "list", "--security"); found {
+		if err != nil {
+			check.Evidence = "dnf: " + err.Error()
+			return check
+		}
+		count := 0
+		for _, line := range strings.Split(out, "\n") {
+			if strings.TrimSpace(line) != "" {
+				count++
+			}
+		}
+		return securityUpdatesResult(check, count, "dnf")
+	}
+
+	check.Evidence = "no supported package manager (apt, dnf) found"
+	return check
+}
+
+func securityUpdatesResult(check SecurityCheck, count int, tool string) SecurityCheck {
+	check.Status = CheckPass
+	if count > 0 {
+		check.Status = CheckFail
+	}
+	check.Evidence = fmt.Sprintf("%d pending security updates (%s, cached package lists)", count, tool)
+	return check
+}
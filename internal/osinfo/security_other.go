@@ -0,0 +1,10 @@
+//go:build !linux
+
+package osinfo
+
+import "context"
+
+// securityChecks is only implemented for Linux
+func securityChecks(ctx context.Context) []SecurityCheck {
+	return []SecurityCheck{}
+}
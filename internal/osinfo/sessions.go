@@ -0,0 +1,155 @@
+package osinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"sentinelgo/internal/config"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// EventUnexpectedRemoteSession is raised when a user outside
+// remote_login_allow_users opens a new remote session
+const EventUnexpectedRemoteSession = "unexpected_remote_session"
+
+// defaultLoginHistoryLimit is the number of wtmp records reported when
+// login_history_limit is not set
+const defaultLoginHistoryLimit = 20
+
+// Session is a current login from utmp
+type Session struct {
+	User     string    `json:"user"`
+	Terminal string    `json:"terminal"`
+	Host     string    `json:"host,omitempty"`
+	Started  time.Time `json:"started"`
+	Remote   bool      `json:"remote"`
+	New      bool      `json:"new"` // not present in the last delivered snapshot
+}
+
+func (s Session) key() string {
+	return s.User + "|" + s.Terminal + "|" + s.Host + "|" + s.Started.UTC().Format(time.RFC3339)
+}
+
+// LoginRecord is a past or current login from the wtmp history
+type LoginRecord struct {
+	User     string     `json:"user"`
+	Terminal string     `json:"terminal"`
+	Host     string     `json:"host,omitempty"`
+	Remote   bool       `json:"remote"`
+	Login    time.Time  `json:"login"`
+	Logout   *time.Time `json:"logout,omitempty"` // unset while still logged in
+}
+
+// sessionBaseline holds the session keys of the last delivered snapshot
+var sessionBaseline = &keyBaseline{fileName: "sessions-baseline.json"}
+
+// isRemoteHost reports whether a utmp host field names a remote peer rather
+// than a local X display (":0") or a terminal multiplexer ("tmux(1234).%0")
+func isRemoteHost(host string) bool {
+	host = strings.TrimSpace(host)
+	if host == "" || strings.HasPrefix(host, ":") || strings.Contains(host, "(") {
+		return false
+	}
+	switch host {
+	case "localhost", "127.0.0.1", "::1":
+		return false
+	}
+	return true
+}
+
+func userAllowed(allowlist []string, user string) bool {
+	for _, allowed := range allowlist {
+		if strings.EqualFold(strings.TrimSpace(allowed), user) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectSessions lists the current sessions and the recent login history,
+// marks sessions not in the last delivered snapshot and raises an event for
+// new remote ones by users outside the allowlist
+func collectSessions(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	// gopsutil has no session source on Windows and always fails there
+	if runtime.GOOS == "windows" {
+		return nil, nil
+	}
+	users, err := host.UsersWithContext(ctx)
+	// Containers and minimal systems often have no utmp at all
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var sessions []Session
+	for _, u := range users {
+		s := Session{
+			User:     u.User,
+			Terminal: u.Terminal,
+			Host:     u.Host,
+			Started:  time.Unix(int64(u.Started), 0).UTC(),
+			Remote:   isRemoteHost(u.Host),
+		}
+		if seen[s.key()] {
+			continue
+		}
+		seen[s.key()] = true
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Started.Before(sessions[j].Started) })
+
+	previous := sessionBaseline.get(cfg)
+
+	var events []Event
+	for i := range sessions {
+		s := &sessions[i]
+		// Nothing is new until a first snapshot has been delivered
+		s.New = previous != nil && !previous[s.key()]
+		if !s.New || !s.Remote || len(cfg.RemoteLoginAllowUsers) == 0 || userAllowed(cfg.RemoteLoginAllowUsers, s.User) {
+			continue
+		}
+		events = append(events, Event{
+			Type: EventUnexpectedRemoteSession,
+			Details: map[string]any{
+				"user":     s.User,
+				"terminal": s.Terminal,
+				"host":     s.Host,
+				"started":  s.Started,
+			},
+			key: s.key(),
+		})
+		fmt.Printf("Unexpected remote session: %s on %s from %s\n", s.User, s.Terminal, s.Host)
+	}
+
+	limit := cfg.LoginHistoryLimit
+	if limit == 0 {
+		limit = defaultLoginHistoryLimit
+	}
+	var history []LoginRecord
+	if limit > 0 {
+		// The history is supplementary; current sessions are still reported
+		// if wtmp cannot be read
+		if history, err = loginHistory(limit); err != nil {
+			fmt.Printf("Warning: failed to read login history: %v\n", err)
+		}
+	}
+
+	return func(info *SystemInfo) {
+		info.Sessions = sessions
+		info.LoginHistory = history
+		info.Events = append(info.Events, events...)
+		info.sessionKeys = seen
+	}, nil
+}
+
+// deliveredSessions makes the delivered sessions the baseline for New
+func deliveredSessions(cfg *config.Config, keys map[string]bool) {
+	sessionBaseline.set(cfg, keys)
+}
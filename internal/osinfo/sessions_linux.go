@@ -0,0 +1,120 @@
+package osinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	wtmpPath = "/var/log/wtmp"
+	// utmpRecordSize is sizeof(struct utmp) on glibc, which keeps 32-bit
+	// timestamps on 64-bit platforms for compatibility
+	utmpRecordSize = 384
+	// wtmpMaxRead bounds how much of the end of wtmp is parsed
+	wtmpMaxRead = 512 * utmpRecordSize
+
+	utmpBootTime    = 2
+	utmpUserProcess = 7
+	utmpDeadProcess = 8
+)
+
+// utmpRecord is the subset of struct utmp used for the login history
+type utmpRecord struct {
+	Type int16
+	Line string
+	User string
+	Host string
+	Time time.Time
+}
+
+func parseUtmpRecord(b []byte) utmpRecord {
+	cstr := func(field []byte) string {
+		if i := bytes.IndexByte(field, 0); i >= 0 {
+			field = field[:i]
+		}
+		return string(field)
+	}
+	sec := int32(binary.LittleEndian.Uint32(b[340:344]))
+	return utmpRecord{
+		Type: int16(binary.LittleEndian.Uint16(b[0:2])),
+		Line: cstr(b[8:40]),
+		User: cstr(b[44:76]),
+		Host: cstr(b[76:332]),
+		Time: time.Unix(int64(sec), 0).UTC(),
+	}
+}
+
+// loginHistory returns the most recent logins from wtmp, newest first, with
+// their logout time when the session has ended
+func loginHistory(limit int) ([]LoginRecord, error) {
+	f, err := os.Open(wtmpPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size() - info.Size()%utmpRecordSize
+	offset := size - wtmpMaxRead
+	if offset < 0 {
+		offset = 0
+	}
+	data := make([]byte, size-offset)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var logins []LoginRecord
+	open := map[string]int{} // terminal -> index of its open login
+	closeAt := func(i int, t time.Time) {
+		logout := t
+		logins[i].Logout = &logout
+	}
+	for len(data) >= utmpRecordSize {
+		r := parseUtmpRecord(data[:utmpRecordSize])
+		data = data[utmpRecordSize:]
+
+		switch r.Type {
+		case utmpUserProcess:
+			if i, ok := open[r.Line]; ok {
+				closeAt(i, r.Time)
+			}
+			open[r.Line] = len(logins)
+			logins = append(logins, LoginRecord{
+				User:     r.User,
+				Terminal: r.Line,
+				Host:     r.Host,
+				Remote:   isRemoteHost(r.Host),
+				Login:    r.Time,
+			})
+		case utmpDeadProcess:
+			if i, ok := open[r.Line]; ok {
+				closeAt(i, r.Time)
+				delete(open, r.Line)
+			}
+		case utmpBootTime:
+			// A reboot ends every session still open
+			for line, i := range open {
+				closeAt(i, r.Time)
+				delete(open, line)
+			}
+		}
+	}
+
+	if len(logins) > limit {
+		logins = logins[len(logins)-limit:]
+	}
+	for i, j := 0, len(logins)-1; i < j; i, j = i+1, j-1 {
+		logins[i], logins[j] = logins[j], logins[i]
+	}
+	return logins, nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package osinfo
+
+// loginHistory is only implemented for the Linux wtmp format
+func loginHistory(limit int) ([]LoginRecord, error) {
+	return nil, nil
+}
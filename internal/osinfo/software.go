@@ -0,0 +1,204 @@
+package osinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sentinelgo/internal/config"
+)
+
+const (
+	softwareStateFileName = "software-inventory.json"
+	// defaultSoftwareInventoryInterval is how often the full inventory is sent
+	defaultSoftwareInventoryInterval = 24 * time.Hour
+)
+
+// Package is one installed software package
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Arch    string `json:"arch,omitempty"`
+	Source  string `json:"source"` // dpkg, rpm, snap, flatpak
+}
+
+func (p Package) key() string {
+	return p.Source + ":" + p.Name + ":" + p.Arch
+}
+
+// PackageUpgrade is a package whose version changed
+type PackageUpgrade struct {
+	Package
+	PreviousVersion string `json:"previous_version"`
+}
+
+// SoftwareReport carries either the full inventory or the changes since the
+// last delivered one
+type SoftwareReport struct {
+	Full     bool             `json:"full"`
+	Count    int              `json:"count"`
+	Packages []Package        `json:"packages,omitempty"`
+	Added    []Package        `json:"added,omitempty"`
+	Removed  []Package        `json:"removed,omitempty"`
+	Upgraded []PackageUpgrade `json:"upgraded,omitempty"`
+
+	inventory []Package // becomes the baseline once delivered
+}
+
+// softwareState is the last inventory known to have reached the backend
+type softwareState struct {
+	FullSentAt time.Time `json:"full_sent_at"`
+	Packages   []Package `json:"packages"`
+}
+
+var softwareStateMu sync.Mutex
+
+func softwareStatePath(cfg *config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.Path), softwareStateFileName)
+}
+
+func loadSoftwareState(cfg *config.Config) *softwareState {
+	state := &softwareState{}
+	data, err := os.ReadFile(softwareStatePath(cfg))
+	if err != nil {
+		return state
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		fmt.Printf("Warning: ignoring unreadable software inventory state: %v\n", err)
+		return &softwareState{}
+	}
+	return state
+}
+
+func (s *softwareState) save(cfg *config.Config) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	path := softwareStatePath(cfg)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// diffPackages compares two inventories
+func diffPackages(old, cur []Package) (added, removed []Package, upgraded []PackageUpgrade) {
+	before := map[string]Package{}
+	for _, p := range old {
+		before[p.key()] = p
+	}
+	seen := map[string]bool{}
+	for _, p := range cur {
+		seen[p.key()] = true
+		prev, ok := before[p.key()]
+		switch {
+		case !ok:
+			added = append(added, p)
+		case prev.Version != p.Version:
+			upgraded = append(upgraded, PackageUpgrade{Package: p, PreviousVersion: prev.Version})
+		}
+	}
+	for _, p := range old {
+		if !seen[p.key()] {
+			removed = append(removed, p)
+		}
+	}
+	return added, removed, upgraded
+}
+
+// softwareReport builds the report for the current inventory: the full list
+// when none has been delivered within the inventory interval, otherwise the
+// changes, or nil if there are none. Sources in failed could not be listed:
+// they are left out of the diff and their last known packages stay in the
+// baseline, so a transient failure is not reported as removals.
+func softwareReport(cfg *config.Config, inventory []Package, failed map[string]error) *SoftwareReport {
+	sort.Slice(inventory, func(i, j int) bool { return inventory[i].key() < inventory[j].key() })
+
+	softwareStateMu.Lock()
+	state := loadSoftwareState(cfg)
+	softwareStateMu.Unlock()
+
+	interval := cfg.GetSoftwareInventoryInterval()
+	if interval <= 0 {
+		interval = defaultSoftwareInventoryInterval
+	}
+
+	previous := state.Packages
+	baseline := inventory
+	if len(failed) > 0 {
+		previous, baseline = nil, append([]Package(nil), inventory...)
+		for _, p := range state.Packages {
+			if _, ok := failed[p.Source]; ok {
+				baseline = append(baseline, p)
+			} else {
+				previous = append(previous, p)
+			}
+		}
+		sort.Slice(baseline, func(i, j int) bool { return baseline[i].key() < baseline[j].key() })
+	}
+
+	report := &SoftwareReport{Count: len(inventory), inventory: baseline}
+	if state.FullSentAt.IsZero() || time.Since(state.FullSentAt) >= interval {
+		report.Full = true
+		report.Packages = inventory
+		return report
+	}
+
+	report.Added, report.Removed, report.Upgraded = diffPackages(previous, inventory)
+	if len(report.Added) == 0 && len(report.Removed) == 0 && len(report.Upgraded) == 0 {
+		return nil
+	}
+	return report
+}
+
+// deliveredSoftware makes the reported inventory the new baseline
+func deliveredSoftware(cfg *config.Config, report *SoftwareReport) {
+	if report == nil {
+		return
+	}
+	softwareStateMu.Lock()
+	defer softwareStateMu.Unlock()
+
+	state := loadSoftwareState(cfg)
+	if report.Full {
+		state.FullSentAt = time.Now().UTC()
+	}
+	state.Packages = report.inventory
+	if err := state.save(cfg); err != nil {
+		fmt.Printf("Warning: failed to save software inventory state: %v\n", err)
+	}
+}
+
+func collectSoftware(ctx context.Context, cfg *config.Config) (func(*SystemInfo), error) {
+	inventory, failed := installedPackages(ctx)
+	// Every source would have been cut short; report nothing rather than a
+	// partial inventory
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if inventory == nil && len(failed) == 0 {
+		return nil, nil
+	}
+	report := softwareReport(cfg, inventory, failed)
+	apply := func(info *SystemInfo) { info.Software = report }
+
+	if len(failed) == 0 {
+		return apply, nil
+	}
+	sources := make([]string, 0, len(failed))
+	for source, err := range failed {
+		sources = append(sources, fmt.Sprintf("%s: %v", source, err))
+	}
+	sort.Strings(sources)
+	// The other sources are still reported
+	return apply, errors.New(strings.Join(sources, "; "))
+}
@@ -0,0 +1,187 @@
+package osinfo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const dpkgStatusPath = "/var/lib/dpkg/status"
+
+// installedPackages lists dpkg (or, without dpkg, rpm), snap and flatpak
+// packages. Package managers that are not installed are skipped; one that
+// fails is reported in failed, by source, and the others are still listed.
+func installedPackages(ctx context.Context) ([]Package, map[string]error) {
+	var packages []Package
+	failed := map[string]error{}
+
+	if f, err := os.Open(dpkgStatusPath); err == nil {
+		pkgs, err := parseDpkgStatus(f)
+		f.Close()
+		if err != nil {
+			failed["dpkg"] = err
+		} else {
+			packages = append(packages, pkgs...)
+		}
+	} else if pkgs, err := rpmPackages(ctx); err != nil {
+		failed["rpm"] = err
+	} else {
+		packages = append(packages, pkgs...)
+	}
+
+	// snapd is often installed but not running ("cannot communicate with server")
+	if snaps, err := snapPackages(ctx); err != nil {
+		failed["snap"] = err
+	} else {
+		packages = append(packages, snaps...)
+	}
+
+	if flatpaks, err := flatpakPackages(ctx); err != nil {
+		failed["flatpak"] = err
+	} else {
+		packages = append(packages, flatpaks...)
+	}
+	return packages, failed
+}
+
+// parseDpkgStatus reads a dpkg status file and returns the packages whose
+// status is "installed"
+func parseDpkgStatus(r io.Reader) ([]Package, error) {
+	var packages []Package
+	var pkg Package
+	var installed bool
+
+	flush := func() {
+		if pkg.Name != "" && installed {
+			pkg.Source = "dpkg"
+			packages = append(packages, pkg)
+		}
+		pkg, installed = Package{}, false
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		// Continuation lines belong to multi-line fields such as Description
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Package":
+			pkg.Name = value
+		case "Version":
+			pkg.Version = value
+		case "Architecture":
+			pkg.Arch = value
+		case "Status":
+			// "want flag status", e.g. "install ok installed"
+			fields := strings.Fields(value)
+			installed = len(fields) == 3 && fields[2] == "installed"
+		}
+	}
+	flush()
+	return packages, scanner.Err()
+}
+
+// runPackageTool runs a package manager, returning no output and no error
+// when it is not installed
+func runPackageTool(ctx context.Context, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", nil
+	}
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+func rpmPackages(ctx context.Context) ([]Package, error) {
+	out, err := runPackageTool(ctx, "rpm", "-qa", "--queryformat", `%{NAME}\t%{VERSION}-%{RELEASE}\t%{ARCH}\n`)
+	if err != nil {
+		return nil, err
+	}
+	return parseRPMOutput(out), nil
+}
+
+// parseRPMOutput parses tab separated name, version-release, arch lines
+func parseRPMOutput(out string) []Package {
+	var packages []Package
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 3 || fields[0] == "" || strings.HasPrefix(fields[0], "gpg-pubkey") {
+			continue
+		}
+		arch := fields[2]
+		if arch == "(none)" {
+			arch = ""
+		}
+		packages = append(packages, Package{Name: fields[0], Version: fields[1], Arch: arch, Source: "rpm"})
+	}
+	return packages
+}
+
+func snapPackages(ctx context.Context) ([]Package, error) {
+	out, err := runPackageTool(ctx, "snap", "list")
+	if err != nil {
+		// snap list fails when snapd is installed but no snaps are
+		if strings.Contains(err.Error(), "No snaps are installed") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return parseSnapList(out), nil
+}
+
+// parseSnapList parses `snap list`: a header line, then Name Version Rev ...
+func parseSnapList(out string) []Package {
+	var packages []Package
+	for i, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 2 {
+			continue
+		}
+		packages = append(packages, Package{Name: fields[0], Version: fields[1], Source: "snap"})
+	}
+	return packages
+}
+
+func flatpakPackages(ctx context.Context) ([]Package, error) {
+	out, err := runPackageTool(ctx, "flatpak", "list", "--columns=application,version,arch")
+	if err != nil {
+		return nil, err
+	}
+	return parseFlatpakList(out), nil
+}
+
+// parseFlatpakList parses tab separated application, version, arch lines
+func parseFlatpakList(out string) []Package {
+	var packages []Package
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) < 3 || fields[0] == "" || fields[0] == "Application ID" {
+			continue
+		}
+		packages = append(packages, Package{Name: fields[0], Version: fields[1], Arch: fields[2], Source: "flatpak"})
+	}
+	return packages
+}
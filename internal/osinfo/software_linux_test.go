@@ -0,0 +1,77 @@
+package osinfo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestPackageParsers(t *testing.T) {
+	tests := []struct {
+		fixture string
+		parse   func(t *testing.T, out string) []Package
+		want    []Package
+	}{
+		{
+			fixture: "dpkg-status",
+			parse: func(t *testing.T, out string) []Package {
+				pkgs, err := parseDpkgStatus(strings.NewReader(out))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return pkgs
+			},
+			want: []Package{
+				{Name: "bash", Version: "5.1-6ubuntu1", Arch: "amd64", Source: "dpkg"},
+				{Name: "python3", Version: "3.10.6-1~22.04", Arch: "amd64", Source: "dpkg"},
+				{Name: "tzdata", Version: "2024a-0ubuntu0.22.04", Arch: "all", Source: "dpkg"},
+			},
+		},
+		{
+			fixture: "rpm-qa.txt",
+			parse:   func(t *testing.T, out string) []Package { return parseRPMOutput(out) },
+			want: []Package{
+				{Name: "bash", Version: "5.1.8-6.el9", Arch: "x86_64", Source: "rpm"},
+				{Name: "kernel-core", Version: "5.14.0-362.8.1.el9_3", Arch: "x86_64", Source: "rpm"},
+				{Name: "basesystem", Version: "11-13.el9", Arch: "noarch", Source: "rpm"},
+				{Name: "tzdata", Version: "2023c-1.el9", Source: "rpm"},
+			},
+		},
+		{
+			fixture: "snap-list.txt",
+			parse:   func(t *testing.T, out string) []Package { return parseSnapList(out) },
+			want: []Package{
+				{Name: "core22", Version: "20240111", Source: "snap"},
+				{Name: "firefox", Version: "122.0-2", Source: "snap"},
+				{Name: "snapd", Version: "2.61.1", Source: "snap"},
+			},
+		},
+		{
+			fixture: "flatpak-list.txt",
+			parse:   func(t *testing.T, out string) []Package { return parseFlatpakList(out) },
+			want: []Package{
+				{Name: "org.mozilla.firefox", Version: "122.0", Arch: "x86_64", Source: "flatpak"},
+				{Name: "org.freedesktop.Platform", Version: "23.08.12", Arch: "x86_64", Source: "flatpak"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got := tt.parse(t, readFixture(t, tt.fixture))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package osinfo
+
+import "context"
+
+// installedPackages is only implemented for Linux package managers
+func installedPackages(ctx context.Context) ([]Package, map[string]error) {
+	return nil, nil
+}
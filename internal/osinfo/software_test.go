@@ -0,0 +1,42 @@
+package osinfo
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sentinelgo/internal/config"
+)
+
+func TestSoftwareReportIgnoresFailedSources(t *testing.T) {
+	cfg := &config.Config{Path: filepath.Join(t.TempDir(), "config.json")}
+	state := &softwareState{
+		FullSentAt: time.Now().UTC(),
+		Packages: []Package{
+			{Name: "bash", Version: "5.1", Source: "dpkg"},
+			{Name: "curl", Version: "7.81", Source: "dpkg"},
+			{Name: "firefox", Version: "122.0", Source: "snap"},
+		},
+	}
+	if err := state.save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// snap failed and curl was removed
+	inventory := []Package{{Name: "bash", Version: "5.1", Source: "dpkg"}}
+	report := softwareReport(cfg, inventory, map[string]error{"snap": errors.New("cannot communicate with server")})
+	if report == nil {
+		t.Fatal("expected a report for the removed package")
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Name != "curl" {
+		t.Errorf("Removed = %+v, want only curl", report.Removed)
+	}
+
+	// The failed source's packages stay in the baseline
+	deliveredSoftware(cfg, report)
+	baseline := loadSoftwareState(cfg).Packages
+	if len(baseline) != 2 || baseline[1].Name != "firefox" {
+		t.Errorf("baseline = %+v, want bash and firefox", baseline)
+	}
+}
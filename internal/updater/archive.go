@@ -0,0 +1,359 @@
+package updater
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"sentinelgo/internal/config"
+)
+
+// Archive kinds recognised in release asset names
+const (
+	archiveNone  = ""
+	archiveTarGz = "tar.gz"
+	archiveZip   = "zip"
+)
+
+// maxArchiveEntrySize caps a single extracted file to guard against archive bombs
+const maxArchiveEntrySize = 512 << 20
+
+// stagedFile is a file extracted from a release archive, waiting to be installed
+type stagedFile struct {
+	RelPath string // Path relative to the install directory
+	Staged  string // Absolute path of the extracted copy
+	Mode    os.FileMode
+	Binary  bool // True for the agent executable itself
+}
+
+// archiveKind returns the archive format of an asset based on its name
+func archiveKind(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(lower, ".zip"):
+		return archiveZip
+	default:
+		return archiveNone
+	}
+}
+
+// isAgentBinary reports whether an archive entry is the agent executable for goos/goarch
+func isAgentBinary(name, goos, goarch string) bool {
+	base := path.Base(name)
+	suffix := ""
+	if goos == "windows" {
+		suffix = ".exe"
+	}
+	return base == "sentinelgo"+suffix || base == fmt.Sprintf("sentinelgo-%s-%s%s", goos, goarch, suffix)
+}
+
+// safeEntryPath validates an archive entry name and returns it as a clean relative path.
+// Absolute paths, drive letters and any ".." component are rejected.
+func safeEntryPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	// tar strips leading "../" when the release workflow archives sibling directories,
+	// but an entry that still carries one is treated as hostile.
+	clean := path.Clean(name)
+	if clean == "." || clean == "" {
+		return "", fmt.Errorf("empty entry name %q", name)
+	}
+	if path.IsAbs(clean) || strings.Contains(clean, ":") {
+		return "", fmt.Errorf("absolute entry path %q", name)
+	}
+	for _, part := range strings.Split(clean, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("entry %q escapes archive root", name)
+		}
+	}
+	if !filepath.IsLocal(filepath.FromSlash(clean)) {
+		return "", fmt.Errorf("entry %q is not a local path", name)
+	}
+	return clean, nil
+}
+
+// extractArchive unpacks archivePath into stagingDir and returns the extracted files.
+// Exactly one agent binary for the current platform must be present.
+func extractArchive(archivePath, kind, stagingDir string) ([]stagedFile, error) {
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return nil, fmt.Errorf("create staging directory: %w", err)
+	}
+
+	var files []stagedFile
+	var err error
+	switch kind {
+	case archiveTarGz:
+		files, err = extractTarGz(archivePath, stagingDir)
+	case archiveZip:
+		files, err = extractZip(archivePath, stagingDir)
+	default:
+		return nil, fmt.Errorf("unsupported archive kind %q", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files = stripCommonRoot(files)
+
+	binaries := 0
+	for i := range files {
+		if isAgentBinary(files[i].RelPath, runtime.GOOS, runtime.GOARCH) {
+			files[i].Binary = true
+			binaries++
+		}
+	}
+	if binaries == 0 {
+		return nil, fmt.Errorf("archive does not contain a sentinelgo binary for %s-%s", runtime.GOOS, runtime.GOARCH)
+	}
+	if binaries > 1 {
+		return nil, fmt.Errorf("archive contains %d sentinelgo binaries for %s-%s", binaries, runtime.GOOS, runtime.GOARCH)
+	}
+
+	return files, nil
+}
+
+func extractTarGz(archivePath, stagingDir string) ([]stagedFile, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("open gzip stream: %w", err)
+	}
+	defer gz.Close()
+
+	var files []stagedFile
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar entry: %w", err)
+		}
+
+		rel, err := safeEntryPath(hdr.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+			// Regular file, extracted below
+		case tar.TypeSymlink, tar.TypeLink:
+			return nil, fmt.Errorf("archive entry %q is a link, refusing to extract", hdr.Name)
+		default:
+			// Device nodes, FIFOs and PAX/GNU metadata entries are never installed
+			continue
+		}
+
+		if hdr.Size > maxArchiveEntrySize {
+			return nil, fmt.Errorf("archive entry %q is too large (%d bytes)", hdr.Name, hdr.Size)
+		}
+
+		staged, err := writeStagedFile(stagingDir, rel, tr, os.FileMode(hdr.Mode).Perm())
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, staged)
+	}
+	return files, nil
+}
+
+func extractZip(archivePath, stagingDir string) ([]stagedFile, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("open zip: %w", err)
+	}
+	defer zr.Close()
+
+	var files []stagedFile
+	for _, zf := range zr.File {
+		rel, err := safeEntryPath(zf.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		mode := zf.Mode()
+		if mode.IsDir() {
+			continue
+		}
+		if mode&os.ModeSymlink != 0 {
+			return nil, fmt.Errorf("archive entry %q is a link, refusing to extract", zf.Name)
+		}
+		if !mode.IsRegular() {
+			continue
+		}
+		if zf.UncompressedSize64 > maxArchiveEntrySize {
+			return nil, fmt.Errorf("archive entry %q is too large (%d bytes)", zf.Name, zf.UncompressedSize64)
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open zip entry %q: %w", zf.Name, err)
+		}
+		staged, err := writeStagedFile(stagingDir, rel, rc, mode.Perm())
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, staged)
+	}
+	return files, nil
+}
+
+// writeStagedFile copies one archive entry into the staging directory
+func writeStagedFile(stagingDir, rel string, r io.Reader, perm os.FileMode) (stagedFile, error) {
+	dest := filepath.Join(stagingDir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return stagedFile{}, fmt.Errorf("create directory for %q: %w", rel, err)
+	}
+	if perm == 0 {
+		perm = 0644
+	}
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return stagedFile{}, fmt.Errorf("create %q: %w", rel, err)
+	}
+	n, err := io.Copy(out, io.LimitReader(r, maxArchiveEntrySize+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return stagedFile{}, fmt.Errorf("extract %q: %w", rel, err)
+	}
+	if n > maxArchiveEntrySize {
+		return stagedFile{}, fmt.Errorf("archive entry %q exceeds %d bytes", rel, maxArchiveEntrySize)
+	}
+
+	return stagedFile{RelPath: rel, Staged: dest, Mode: perm}, nil
+}
+
+// stripCommonRoot removes a single top-level directory shared by every entry,
+// so "sentinelgo-linux-amd64/sentinelgo.service" installs as "sentinelgo.service".
+func stripCommonRoot(files []stagedFile) []stagedFile {
+	if len(files) == 0 {
+		return files
+	}
+	root := ""
+	for _, f := range files {
+		i := strings.Index(f.RelPath, "/")
+		if i < 0 {
+			return files
+		}
+		if root == "" {
+			root = f.RelPath[:i+1]
+		} else if !strings.HasPrefix(f.RelPath, root) {
+			return files
+		}
+	}
+	for i := range files {
+		files[i].RelPath = strings.TrimPrefix(files[i].RelPath, root)
+	}
+	return files
+}
+
+// auxiliaryFiles lists the archive entries, by base name, that are kept from
+// a release. Anything else in the archive is ignored rather than written.
+var auxiliaryFiles = map[string]bool{
+	"INSTALLATION.md":           true,
+	"install.sh":                true,
+	"install-linux-simple.sh":   true,
+	"install-macos-simple.sh":   true,
+	"setup-windows-service.bat": true,
+	"sentinelgo.service":        true,
+	"com.sentinelgo.plist":      true,
+	"config.example.json":       true,
+}
+
+const releaseFilesDirName = "release-files"
+
+// releaseFilesDir is the dedicated directory, next to the config, holding the
+// auxiliary files of the installed release. It is never on PATH and never
+// contains the active config.
+func releaseFilesDir(cfg *config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.Path), releaseFilesDirName)
+}
+
+// pendingReleaseFilesDir holds the auxiliary files of an update until the new
+// version has proven itself healthy
+func pendingReleaseFilesDir(cfg *config.Config) string {
+	return releaseFilesDir(cfg) + ".pending"
+}
+
+// stageAuxiliaryFiles moves the allowlisted staged files into the pending
+// release files directory. Nothing becomes visible in releaseFilesDir until
+// commitAuxiliaryFiles runs in the healthy new version.
+func stageAuxiliaryFiles(cfg *config.Config, files []stagedFile) error {
+	dir := pendingReleaseFilesDir(cfg)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, f := range files {
+		name := path.Base(f.RelPath)
+		if f.Binary {
+			continue
+		}
+		if !auxiliaryFiles[name] {
+			fmt.Printf("Ignoring archive entry %s: not an auxiliary file\n", f.RelPath)
+			continue
+		}
+		if err := os.Rename(f.Staged, filepath.Join(dir, name)); err != nil {
+			os.RemoveAll(dir)
+			return fmt.Errorf("stage %s: %w", f.RelPath, err)
+		}
+	}
+	return nil
+}
+
+// commitAuxiliaryFiles swaps the pending release files into place. The
+// previous set is kept until the swap has succeeded.
+func commitAuxiliaryFiles(cfg *config.Config) error {
+	pending := pendingReleaseFilesDir(cfg)
+	if _, err := os.Stat(pending); err != nil {
+		return nil
+	}
+	dir := releaseFilesDir(cfg)
+	backup := dir + ".old"
+	os.RemoveAll(backup)
+	hadPrevious := false
+	if _, err := os.Stat(dir); err == nil {
+		if err := os.Rename(dir, backup); err != nil {
+			return fmt.Errorf("back up release files: %w", err)
+		}
+		hadPrevious = true
+	}
+	if err := os.Rename(pending, dir); err != nil {
+		if hadPrevious {
+			os.Rename(backup, dir)
+		}
+		return fmt.Errorf("install release files: %w", err)
+	}
+	os.RemoveAll(backup)
+	fmt.Printf("Installed release files in %s\n", dir)
+	return nil
+}
+
+// discardAuxiliaryFiles drops release files staged for an update that did not
+// go through
+func discardAuxiliaryFiles(cfg *config.Config) {
+	os.RemoveAll(pendingReleaseFilesDir(cfg))
+}
@@ -0,0 +1,228 @@
+package updater
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	"sentinelgo/internal/config"
+)
+
+// archiveEntry describes one file of a test archive
+type archiveEntry struct {
+	name     string
+	body     string
+	typeflag byte   // tar only; defaults to a regular file
+	linkname string // for links
+}
+
+// agentBinaryName is the archive name of the agent for the current platform
+func agentBinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "sentinelgo.exe"
+	}
+	return "sentinelgo"
+}
+
+func writeTarGz(t *testing.T, entries []archiveEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0755, Size: int64(len(e.body)), Typeflag: e.typeflag, Linkname: e.linkname}
+		if hdr.Typeflag == 0 {
+			hdr.Typeflag = tar.TypeReg
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			hdr.Size = 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "asset.tar.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeZip(t *testing.T, entries []archiveEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		hdr.SetMode(0755)
+		body := e.body
+		if e.typeflag == tar.TypeSymlink {
+			hdr.SetMode(os.ModeSymlink | 0777)
+			body = e.linkname
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "asset.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSafeEntryPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "sentinelgo", want: "sentinelgo"},
+		{name: "./dir/INSTALLATION.md", want: "dir/INSTALLATION.md"},
+		{name: `dir\install.sh`, want: "dir/install.sh"},
+		{name: "../evil", wantErr: true},
+		{name: "dir/../../evil", wantErr: true},
+		{name: `..\evil`, wantErr: true},
+		{name: "/etc/passwd", wantErr: true},
+		{name: `C:\Windows\evil.exe`, wantErr: true},
+		{name: "C:evil", wantErr: true},
+		{name: ".", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := safeEntryPath(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("safeEntryPath(%q) = %q, want error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("safeEntryPath(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestExtractArchiveRejectsHostileEntries(t *testing.T) {
+	binary := archiveEntry{name: agentBinaryName(), body: "binary"}
+	tests := []struct {
+		name    string
+		kind    string
+		entries []archiveEntry
+	}{
+		{name: "tar parent traversal", kind: archiveTarGz, entries: []archiveEntry{binary, {name: "../evil", body: "x"}}},
+		{name: "zip parent traversal", kind: archiveZip, entries: []archiveEntry{binary, {name: "../evil", body: "x"}}},
+		{name: "tar absolute path", kind: archiveTarGz, entries: []archiveEntry{binary, {name: "/etc/cron.d/evil", body: "x"}}},
+		{name: "zip drive letter", kind: archiveZip, entries: []archiveEntry{binary, {name: `C:\evil.exe`, body: "x"}}},
+		{name: "tar symlink", kind: archiveTarGz, entries: []archiveEntry{binary, {name: "link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}}},
+		{name: "tar hardlink", kind: archiveTarGz, entries: []archiveEntry{binary, {name: "link", typeflag: tar.TypeLink, linkname: "sentinelgo"}}},
+		{name: "zip symlink", kind: archiveZip, entries: []archiveEntry{binary, {name: "link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"}}},
+		{name: "missing binary", kind: archiveTarGz, entries: []archiveEntry{{name: "INSTALLATION.md", body: "docs"}}},
+		{name: "duplicate binary", kind: archiveTarGz, entries: []archiveEntry{binary, {name: "bin/" + agentBinaryName(), body: "binary"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var archive string
+			if tt.kind == archiveZip {
+				archive = writeZip(t, tt.entries)
+			} else {
+				archive = writeTarGz(t, tt.entries)
+			}
+			staging := filepath.Join(t.TempDir(), "staging")
+			if files, err := extractArchive(archive, tt.kind, staging); err == nil {
+				t.Fatalf("extractArchive() = %+v, want error", files)
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(staging), "evil")); err == nil {
+				t.Fatal("entry was written outside the staging directory")
+			}
+		})
+	}
+}
+
+func TestExtractArchiveStripsCommonRoot(t *testing.T) {
+	for _, kind := range []string{archiveTarGz, archiveZip} {
+		t.Run(kind, func(t *testing.T) {
+			entries := []archiveEntry{
+				{name: "sentinelgo-v2.0.0/" + agentBinaryName(), body: "binary"},
+				{name: "sentinelgo-v2.0.0/INSTALLATION.md", body: "docs"},
+			}
+			var archive string
+			if kind == archiveZip {
+				archive = writeZip(t, entries)
+			} else {
+				archive = writeTarGz(t, entries)
+			}
+			files, err := extractArchive(archive, kind, filepath.Join(t.TempDir(), "staging"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var paths []string
+			for _, f := range files {
+				paths = append(paths, f.RelPath)
+				if f.Binary != (f.RelPath == agentBinaryName()) {
+					t.Errorf("%s: Binary = %v", f.RelPath, f.Binary)
+				}
+			}
+			sort.Strings(paths)
+			want := []string{"INSTALLATION.md", agentBinaryName()}
+			sort.Strings(want)
+			if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+				t.Fatalf("RelPaths = %v, want %v", paths, want)
+			}
+		})
+	}
+}
+
+func TestStageAuxiliaryFilesIgnoresUnlistedFiles(t *testing.T) {
+	cfg := &config.Config{Path: filepath.Join(t.TempDir(), "config.json")}
+	archive := writeTarGz(t, []archiveEntry{
+		{name: agentBinaryName(), body: "binary"},
+		{name: "INSTALLATION.md", body: "docs"},
+		{name: "evil.sh", body: "rm -rf /"},
+		{name: "config.json", body: "{}"},
+	})
+	files, err := extractArchive(archive, archiveTarGz, filepath.Join(t.TempDir(), "staging"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stageAuxiliaryFiles(cfg, files); err != nil {
+		t.Fatal(err)
+	}
+	if err := commitAuxiliaryFiles(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(releaseFilesDir(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "INSTALLATION.md" {
+		t.Fatalf("release files = %v, want only INSTALLATION.md", entries)
+	}
+	if _, err := os.Stat(cfg.Path); err == nil {
+		t.Fatal("archive config.json was installed over the active config path")
+	}
+}
@@ -0,0 +1,101 @@
+package updater
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// bsdiffMagic identifies the classic bsdiff 4.x patch format
+const bsdiffMagic = "BSDIFF40"
+
+// maxPatchedSize caps the output of a patch to guard against corrupt headers
+const maxPatchedSize = 512 << 20
+
+var errCorruptPatch = errors.New("corrupt bsdiff patch")
+
+// bspatch applies a BSDIFF40 patch to old and returns the new file contents.
+//
+// The patch is a 32-byte header (magic, control block length, diff block
+// length, new size) followed by three bzip2 streams: control triples, diff
+// bytes added to the old file, and extra bytes copied verbatim.
+func bspatch(old, patch []byte) ([]byte, error) {
+	if len(patch) < 32 || string(patch[:8]) != bsdiffMagic {
+		return nil, fmt.Errorf("%w: bad header", errCorruptPatch)
+	}
+	ctrlLen := offtin(patch[8:16])
+	diffLen := offtin(patch[16:24])
+	newSize := offtin(patch[24:32])
+	// Each length is checked on its own first: their sum can overflow int64
+	bodyLen := int64(len(patch) - 32)
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 || newSize > maxPatchedSize ||
+		ctrlLen > bodyLen || diffLen > bodyLen || ctrlLen+diffLen > bodyLen {
+		return nil, fmt.Errorf("%w: bad block lengths", errCorruptPatch)
+	}
+
+	body := patch[32:]
+	ctrl := bzip2.NewReader(bytes.NewReader(body[:ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(body[ctrlLen : ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(body[ctrlLen+diffLen:]))
+
+	out := make([]byte, newSize)
+	oldSize := int64(len(old))
+	var oldPos, newPos int64
+	var buf [8]byte
+
+	for newPos < newSize {
+		var triple [3]int64
+		for i := range triple {
+			if _, err := io.ReadFull(ctrl, buf[:]); err != nil {
+				return nil, fmt.Errorf("%w: control block: %v", errCorruptPatch, err)
+			}
+			triple[i] = offtin(buf[:])
+		}
+		addLen, copyLen, seek := triple[0], triple[1], triple[2]
+
+		// Diff section: out = old + diff
+		if addLen < 0 || addLen > newSize-newPos {
+			return nil, fmt.Errorf("%w: diff length out of range", errCorruptPatch)
+		}
+		if _, err := io.ReadFull(diff, out[newPos:newPos+addLen]); err != nil {
+			return nil, fmt.Errorf("%w: diff block: %v", errCorruptPatch, err)
+		}
+		for i := int64(0); i < addLen; i++ {
+			if p := oldPos + i; p >= 0 && p < oldSize {
+				out[newPos+i] += old[p]
+			}
+		}
+		newPos += addLen
+		oldPos += addLen
+
+		// Extra section: copied verbatim
+		if copyLen < 0 || copyLen > newSize-newPos {
+			return nil, fmt.Errorf("%w: extra length out of range", errCorruptPatch)
+		}
+		if _, err := io.ReadFull(extra, out[newPos:newPos+copyLen]); err != nil {
+			return nil, fmt.Errorf("%w: extra block: %v", errCorruptPatch, err)
+		}
+		newPos += copyLen
+		oldPos += seek
+	}
+
+	return out, nil
+}
+
+// offtin decodes bsdiff's 8-byte little-endian sign-magnitude integer
+func offtin(b []byte) int64 {
+	var y int64
+	for i := 7; i >= 0; i-- {
+		c := b[i]
+		if i == 7 {
+			c &= 0x7f
+		}
+		y = y<<8 | int64(c)
+	}
+	if b[7]&0x80 != 0 {
+		y = -y
+	}
+	return y
+}
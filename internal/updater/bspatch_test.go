@@ -0,0 +1,79 @@
+package updater
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestBspatchRejectsOverflowingBlockLengths(t *testing.T) {
+	patch := make([]byte, 64)
+	copy(patch, bsdiffMagic)
+	binary.LittleEndian.PutUint64(patch[8:16], 1<<62)
+	binary.LittleEndian.PutUint64(patch[16:24], 1<<62)
+	binary.LittleEndian.PutUint64(patch[24:32], 16)
+
+	if _, err := bspatch(nil, patch); !errors.Is(err, errCorruptPatch) {
+		t.Fatalf("bspatch() error = %v, want errCorruptPatch", err)
+	}
+}
+
+// bzip2 streams generated with Python's bz2 module: control blocks that first
+// add 8 bytes and then claim an add or copy length of MaxInt64-4, whose sum
+// with the output position overflows int64
+var (
+	bzOverflowingAdd = []byte{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xc2, 0x68, 0x08, 0x52, 0x00, 0x00,
+		0x04, 0xe0, 0x80, 0xd0, 0x44, 0x08, 0x00, 0x00, 0x00, 0x80, 0x08, 0xa0, 0x00, 0x21, 0x93, 0x4c,
+		0x9a, 0x10, 0xc0, 0x89, 0xa8, 0x1b, 0x10, 0x9c, 0xcd, 0xf1, 0x77, 0x24, 0x53, 0x85, 0x09, 0x0c,
+		0x26, 0x80, 0x85, 0x20,
+	}
+	bzOverflowingCopy = []byte{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x98, 0xea, 0x01, 0xd2, 0x00, 0x00,
+		0x05, 0xc0, 0x80, 0xdc, 0x40, 0x00, 0x00, 0x80, 0x08, 0xa0, 0x00, 0x31, 0x0c, 0x08, 0x19, 0x0c,
+		0x22, 0x7a, 0x0d, 0xc1, 0x1c, 0x46, 0xf8, 0xbb, 0x92, 0x29, 0xc2, 0x84, 0x84, 0xc7, 0x50, 0x0e,
+		0x90,
+	}
+	// bzEightZeros holds 8 zero bytes, the diff block of both patches
+	bzEightZeros = []byte{
+		0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x96, 0xfb, 0x44, 0xa6, 0x00, 0x00,
+		0x00, 0x40, 0x00, 0x44, 0x00, 0x20, 0x00, 0x21, 0x00, 0x82, 0x83, 0x17, 0x72, 0x45, 0x38, 0x50,
+		0x90, 0x96, 0xfb, 0x44, 0xa6,
+	}
+	bzEmpty = []byte{0x42, 0x5a, 0x68, 0x39, 0x17, 0x72, 0x45, 0x38, 0x50, 0x90, 0x00, 0x00, 0x00, 0x00}
+)
+
+// buildPatch assembles a BSDIFF40 patch from already compressed blocks
+func buildPatch(ctrl, diff, extra []byte, newSize int64) []byte {
+	patch := make([]byte, 32, 32+len(ctrl)+len(diff)+len(extra))
+	copy(patch, bsdiffMagic)
+	binary.LittleEndian.PutUint64(patch[8:16], uint64(len(ctrl)))
+	binary.LittleEndian.PutUint64(patch[16:24], uint64(len(diff)))
+	binary.LittleEndian.PutUint64(patch[24:32], uint64(newSize))
+	patch = append(patch, ctrl...)
+	patch = append(patch, diff...)
+	return append(patch, extra...)
+}
+
+func TestBspatchRejectsOversizedControlLengths(t *testing.T) {
+	tests := []struct {
+		name string
+		ctrl []byte
+	}{
+		{name: "add length", ctrl: bzOverflowingAdd},
+		{name: "copy length", ctrl: bzOverflowingCopy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("bspatch() panicked: %v", r)
+				}
+			}()
+			patch := buildPatch(tt.ctrl, bzEightZeros, bzEmpty, 16)
+			if _, err := bspatch(make([]byte, 16), patch); !errors.Is(err, errCorruptPatch) {
+				t.Fatalf("bspatch() error = %v, want errCorruptPatch", err)
+			}
+		})
+	}
+}
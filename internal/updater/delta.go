@@ -0,0 +1,232 @@
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+
+	"sentinelgo/internal/config"
+)
+
+// checksumManifestNames are the release assets searched for sha256sum-style
+// checksum lists, in order of preference
+var checksumManifestNames = []string{"SHA256SUMS", "SHA256SUMS.txt", "checksums.txt"}
+
+// maxManifestSize bounds how much of a checksum manifest is read
+const maxManifestSize = 1 << 20
+
+// binaryAssetName is the name of the bare binary asset for goos/goarch
+func binaryAssetName(goos, goarch string) string {
+	if goos == "windows" {
+		return fmt.Sprintf("sentinelgo-%s-%s.exe", goos, goarch)
+	}
+	return fmt.Sprintf("sentinelgo-%s-%s", goos, goarch)
+}
+
+// patchAssetName is the name of the bsdiff patch turning version from into to
+func patchAssetName(goos, goarch, from, to string) string {
+	return fmt.Sprintf("sentinelgo-%s-%s-%s-%s.bsdiff", goos, goarch, from, to)
+}
+
+// findAsset returns the release asset with the given name, or nil
+func findAsset(rel *GitHubRelease, name string) *Asset {
+	for i := range rel.Assets {
+		if rel.Assets[i].Name == name {
+			return &rel.Assets[i]
+		}
+	}
+	return nil
+}
+
+// ManifestPublicKey is an optional base64 ed25519 public key the checksum
+// manifest must be signed with, injected at build time with
+// -ldflags "-X sentinelgo/internal/updater.ManifestPublicKey=...". It is empty
+// by default: delta updates are then only checked against unsigned checksums
+// from the same release, which detect corruption but not a forged release.
+var ManifestPublicKey = ""
+
+// expectedBinarySHA256 returns the published SHA-256 of the bare binary for
+// this platform: from a checksum manifest if the release has one, otherwise
+// from the digest GitHub reports for the binary asset. It returns "" when
+// neither is available. Both are unsigned unless ManifestPublicKey is set, in
+// which case only a manifest carrying a valid "<manifest>.sig" is accepted.
+func expectedBinarySHA256(ctx context.Context, rel *GitHubRelease) (string, error) {
+	binary := binaryAssetName(runtime.GOOS, runtime.GOARCH)
+
+	var publicKey ed25519.PublicKey
+	if ManifestPublicKey != "" {
+		key, err := base64.StdEncoding.DecodeString(ManifestPublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return "", fmt.Errorf("invalid manifest public key")
+		}
+		publicKey = key
+	}
+
+	for _, name := range checksumManifestNames {
+		manifest := findAsset(rel, name)
+		if manifest == nil {
+			continue
+		}
+		data, err := fetchSmallAsset(ctx, manifest.URL)
+		if err != nil {
+			return "", fmt.Errorf("fetch %s: %w", name, err)
+		}
+		if publicKey != nil {
+			if err := verifyManifestSignature(ctx, rel, name, data, publicKey); err != nil {
+				return "", err
+			}
+		}
+		if sum, ok := parseChecksumManifest(data)[binary]; ok {
+			return sum, nil
+		}
+	}
+
+	// GitHub's digest is not signed by the publisher
+	if publicKey != nil {
+		return "", nil
+	}
+	if asset := findAsset(rel, binary); asset != nil && strings.HasPrefix(asset.Digest, "sha256:") {
+		return strings.TrimPrefix(asset.Digest, "sha256:"), nil
+	}
+	return "", nil
+}
+
+// verifyManifestSignature checks the detached ed25519 signature published as
+// "<manifest>.sig", either raw or base64 encoded
+func verifyManifestSignature(ctx context.Context, rel *GitHubRelease, name string, data []byte, key ed25519.PublicKey) error {
+	sigAsset := findAsset(rel, name+".sig")
+	if sigAsset == nil {
+		return fmt.Errorf("%s is not signed", name)
+	}
+	sig, err := fetchSmallAsset(ctx, sigAsset.URL)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", sigAsset.Name, err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return fmt.Errorf("%s: malformed signature", sigAsset.Name)
+		}
+		sig = decoded
+	}
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(key, data, sig) {
+		return fmt.Errorf("%s: signature does not match", sigAsset.Name)
+	}
+	return nil
+}
+
+// fetchSmallAsset downloads a manifest or signature, up to maxManifestSize
+func fetchSmallAsset(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+}
+
+// parseChecksumManifest reads a "<hex>  <name>" manifest into a name->hash map
+func parseChecksumManifest(data []byte) map[string]string {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks binary-mode entries with a leading '*'
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return sums
+}
+
+// tryDeltaUpdate builds "<exe>.new" by applying a published bsdiff patch to
+// the running executable. It reports false, leaving nothing behind, whenever
+// the full download should be used instead: no patch for this version pair,
+// no published checksum to verify against, or any download, patch or
+// checksum failure.
+func tryDeltaUpdate(ctx context.Context, cfg *config.Config, rel *GitHubRelease) (string, string, bool) {
+	patch := findAsset(rel, patchAssetName(runtime.GOOS, runtime.GOARCH, cfg.CurrentVersion, rel.TagName))
+	if patch == nil {
+		return "", "", false
+	}
+
+	want, err := expectedBinarySHA256(ctx, rel)
+	if err != nil || want == "" {
+		fmt.Printf("Skipping delta update %s: no usable checksum for the patched binary (%v)\n", patch.Name, err)
+		return "", "", false
+	}
+
+	selfPath, err := os.Executable()
+	if err != nil {
+		return "", "", false
+	}
+	newPath := selfPath + ".new"
+	patchPath := selfPath + ".patch"
+	defer os.Remove(patchPath)
+
+	entry := HistoryEntry{Event: EventDownload, FromVersion: cfg.CurrentVersion, ToVersion: rel.TagName, Asset: patch.Name}
+	fail := func(err error) (string, string, bool) {
+		os.Remove(newPath)
+		recordHistory(cfg, entry, fmt.Errorf("delta update failed, falling back to full download: %w", err))
+		fmt.Printf("Delta update failed (%v), falling back to full download\n", err)
+		return "", "", false
+	}
+
+	fmt.Printf("Using delta update %s (%d bytes)\n", patch.Name, patch.Size)
+	d := download{
+		URL:         patch.URL,
+		Dest:        patchPath,
+		Part:        partPath(selfPath, patch, rel.TagName),
+		Perm:        0644,
+		Size:        patch.Size,
+		BytesPerSec: cfg.UpdateBandwidthLimit,
+	}
+	if err := downloadFile(ctx, d); err != nil {
+		return fail(err)
+	}
+
+	oldData, err := os.ReadFile(selfPath)
+	if err != nil {
+		return fail(err)
+	}
+	patchData, err := os.ReadFile(patchPath)
+	if err != nil {
+		return fail(err)
+	}
+	newData, err := bspatch(oldData, patchData)
+	if err != nil {
+		return fail(err)
+	}
+	if err := os.WriteFile(newPath, newData, 0755); err != nil {
+		return fail(err)
+	}
+
+	got, err := fileSHA256(newPath)
+	if err != nil {
+		return fail(err)
+	}
+	if !strings.EqualFold(got, want) {
+		return fail(fmt.Errorf("patched binary sha256 %s does not match published %s", got, want))
+	}
+
+	entry.SHA256 = got
+	recordHistory(cfg, entry, nil)
+	fmt.Printf("Delta update applied, sha256 %s matches the release checksum\n", got)
+	return newPath, got, true
+}
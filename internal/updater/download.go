@@ -0,0 +1,297 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+const (
+	// downloadAttempts is how many times an interrupted download is resumed
+	downloadAttempts = 5
+	// freeSpaceMargin is kept free on the target filesystem after an update is staged
+	freeSpaceMargin = 50 << 20
+	// stalePartAge is how long a partial download is kept for resuming
+	stalePartAge = 24 * time.Hour
+)
+
+// errRangeMismatch means the server ignored or misapplied the Range header
+var errRangeMismatch = errors.New("server returned unexpected content range")
+
+// download describes one file fetched by downloadFile
+type download struct {
+	URL         string
+	Dest        string
+	Part        string // Partial file, kept across interruptions for resuming
+	Perm        os.FileMode
+	Size        int64 // Expected size in bytes, 0 if unknown
+	BytesPerSec int64 // Throughput limit, 0 for unlimited
+}
+
+// partPath returns the partial download path for an asset of a given version.
+// It is keyed by both so a leftover from another release is never resumed,
+// and ends in the expected size so cleanup can recognise a complete download.
+func partPath(selfPath string, asset *Asset, version string) string {
+	key := strings.NewReplacer("/", "_", "\\", "_", " ", "_").Replace(version + "-" + asset.Name)
+	return fmt.Sprintf("%s.%s.%d.part", selfPath, key, asset.Size)
+}
+
+// partComplete reports whether a part file named by partPath already holds
+// its expected size
+func partComplete(path string, size int64) bool {
+	name := strings.TrimSuffix(path, ".part")
+	expected, err := strconv.ParseInt(name[strings.LastIndex(name, ".")+1:], 10, 64)
+	return err == nil && expected > 0 && size == expected
+}
+
+// removeOtherParts deletes every part file next to selfPath except keep, so
+// a superseded prefetched release does not linger
+func removeOtherParts(selfPath, keep string) {
+	parts, _ := filepath.Glob(selfPath + ".*.part")
+	for _, p := range parts {
+		if p != keep {
+			os.Remove(p)
+		}
+	}
+}
+
+// downloadFile downloads d.URL into d.Dest. The body is written to d.Part,
+// which survives interruptions: later attempts (and later update runs) resume
+// it with an HTTP Range request. d.Size, when known, is checked against the
+// Content-Length and the final file.
+func downloadFile(ctx context.Context, d download) error {
+	if err := fetchPart(ctx, d); err != nil {
+		return err
+	}
+	if err := os.Chmod(d.Part, d.Perm); err != nil {
+		return err
+	}
+	if err := os.Rename(d.Part, d.Dest); err != nil {
+		return fmt.Errorf("move download into place: %w", err)
+	}
+	return nil
+}
+
+// fetchPart completes d.Part, resuming and retrying as needed, and verifies
+// its size. A complete part file is picked up by the next downloadFile call
+// without any network traffic.
+func fetchPart(ctx context.Context, d download) error {
+	var lastErr error
+	for attempt := 1; attempt <= downloadAttempts; attempt++ {
+		if attempt > 1 {
+			wait := time.Duration(attempt-1) * 5 * time.Second
+			fmt.Printf("Download interrupted (%v), resuming in %v (attempt %d/%d)\n", lastErr, wait, attempt, downloadAttempts)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		lastErr = downloadAttempt(ctx, d)
+		if lastErr == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(lastErr, errRangeMismatch) {
+			// Start over rather than splice mismatched data
+			os.Remove(d.Part)
+		}
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+
+	info, err := os.Stat(d.Part)
+	if err != nil {
+		return err
+	}
+	if d.Size > 0 && info.Size() != d.Size {
+		os.Remove(d.Part)
+		return fmt.Errorf("downloaded %d bytes, expected %d", info.Size(), d.Size)
+	}
+	return nil
+}
+
+// downloadAttempt fetches the remainder of d.URL into d.Part
+func downloadAttempt(ctx context.Context, d download) error {
+	size := d.Size
+	var offset int64
+	if info, err := os.Stat(d.Part); err == nil {
+		offset = info.Size()
+	}
+	if size > 0 && offset == size {
+		return nil // Already complete from a previous run
+	}
+	if size > 0 && offset > size {
+		os.Remove(d.Part)
+		offset = 0
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", d.URL, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		fmt.Printf("Resuming download at byte %d\n", offset)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// Full body: either no Range was sent or the server ignored it
+		offset = 0
+		flags |= os.O_TRUNC
+	case http.StatusPartialContent:
+		start, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			return errRangeMismatch
+		}
+		flags |= os.O_APPEND
+	case http.StatusRequestedRangeNotSatisfiable:
+		return errRangeMismatch
+	default:
+		return fmt.Errorf("download failed status %d", resp.StatusCode)
+	}
+
+	if size > 0 && resp.ContentLength >= 0 && offset+resp.ContentLength != size {
+		return fmt.Errorf("content length %d at offset %d does not match asset size %d", resp.ContentLength, offset, size)
+	}
+
+	if err := checkFreeSpace(filepath.Dir(d.Part), remaining(size, offset, resp.ContentLength)); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(d.Part, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var body io.Reader = resp.Body
+	if d.BytesPerSec > 0 {
+		body = &rateLimitedReader{ctx: ctx, r: resp.Body, bytesPerSec: d.BytesPerSec, start: time.Now()}
+	}
+
+	n, err := io.Copy(f, body)
+	if err != nil {
+		return err
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return fmt.Errorf("short read: got %d of %d bytes", n, resp.ContentLength)
+	}
+	return f.Close()
+}
+
+// contentRangeStart parses the first byte offset from "bytes <start>-<end>/<size>"
+func contentRangeStart(header string) (int64, error) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	startStr, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	return strconv.ParseInt(startStr, 10, 64)
+}
+
+// remaining returns the number of bytes still to be written, or 0 if unknown
+func remaining(size, offset, contentLength int64) int64 {
+	if size > 0 {
+		return size - offset
+	}
+	if contentLength > 0 {
+		return contentLength
+	}
+	return 0
+}
+
+// checkFreeSpace fails if dir's filesystem cannot hold need bytes plus a safety margin
+func checkFreeSpace(dir string, need int64) error {
+	usage, err := disk.Usage(dir)
+	if err != nil {
+		fmt.Printf("Warning: could not check free space on %s: %v\n", dir, err)
+		return nil
+	}
+	required := uint64(need) + freeSpaceMargin
+	if usage.Free < required {
+		return fmt.Errorf("insufficient disk space on %s: %d bytes free, %d required", dir, usage.Free, required)
+	}
+	return nil
+}
+
+// rateLimitedReader throttles reads to roughly bytesPerSec
+type rateLimitedReader struct {
+	ctx         context.Context
+	r           io.Reader
+	bytesPerSec int64
+	start       time.Time
+	read        int64
+}
+
+func (l *rateLimitedReader) Read(p []byte) (int, error) {
+	// Keep individual reads small so the pacing stays smooth
+	if chunk := l.bytesPerSec / 4; chunk > 0 && int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+
+	due := time.Duration(float64(l.read) / float64(l.bytesPerSec) * float64(time.Second))
+	if wait := due - time.Since(l.start); wait > 0 {
+		select {
+		case <-l.ctx.Done():
+			return n, l.ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	return n, err
+}
+
+// CleanupStaleFiles removes leftovers of interrupted updates next to the
+// running executable: staged binaries, extraction directories and partial
+// downloads too old to be worth resuming. Complete downloads, such as those
+// prefetched in download mode, are kept until a newer one replaces them.
+func CleanupStaleFiles() {
+	selfPath, err := os.Executable()
+	if err != nil {
+		return
+	}
+
+	for _, p := range []string{selfPath + ".new", selfPath + ".download", selfPath + ".staging"} {
+		if _, err := os.Stat(p); err == nil {
+			fmt.Printf("Removing stale update file: %s\n", p)
+			os.RemoveAll(p)
+		}
+	}
+
+	// Partial downloads are named by partPath
+	parts, _ := filepath.Glob(selfPath + ".*.part")
+	for _, p := range parts {
+		info, err := os.Stat(p)
+		if err != nil || time.Since(info.ModTime()) < stalePartAge || partComplete(p, info.Size()) {
+			continue
+		}
+		fmt.Printf("Removing stale partial download: %s\n", p)
+		os.Remove(p)
+	}
+}
@@ -0,0 +1,25 @@
+package updater
+
+import "testing"
+
+func TestPartComplete(t *testing.T) {
+	asset := &Asset{Name: "sentinelgo-linux-amd64.tar.gz", Size: 1234}
+	part := partPath("/opt/sentinelgo/sentinelgo", asset, "v2.0.0")
+	if part != "/opt/sentinelgo/sentinelgo.v2.0.0-sentinelgo-linux-amd64.tar.gz.1234.part" {
+		t.Fatalf("partPath() = %q", part)
+	}
+	if !partComplete(part, 1234) {
+		t.Error("part with the expected size is not complete")
+	}
+	if partComplete(part, 1000) {
+		t.Error("truncated part is complete")
+	}
+	// Parts of an unknown size and the older naming only age out
+	unknown := partPath("/opt/sentinelgo/sentinelgo", &Asset{Name: "sentinelgo"}, "v2.0.0")
+	if partComplete(unknown, 0) {
+		t.Error("part of unknown size is complete")
+	}
+	if partComplete("/opt/sentinelgo/sentinelgo.v2.0.0-sentinelgo.tar.gz.part", 1234) {
+		t.Error("part without a size is complete")
+	}
+}
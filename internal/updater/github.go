@@ -0,0 +1,193 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"sentinelgo/internal/config"
+)
+
+const (
+	releaseCacheFileName = "release-cache.json"
+	// defaultRateLimitBackoff is used when GitHub refuses a request without saying until when
+	defaultRateLimitBackoff = 15 * time.Minute
+)
+
+// releaseCache persists GitHub API responses so repeated checks can be sent as
+// conditional requests, and remembers when the API rate limit resets.
+type releaseCache struct {
+	Entries        map[string]cachedRelease `json:"entries"`
+	RateLimitReset time.Time                `json:"rate_limit_reset,omitempty"`
+}
+
+type cachedRelease struct {
+	ETag      string        `json:"etag"`
+	Release   GitHubRelease `json:"release"`
+	FetchedAt time.Time     `json:"fetched_at"`
+}
+
+// releaseCacheMu serialises access to the cache file within this process
+var releaseCacheMu sync.Mutex
+
+// githubAPIURL is the GitHub REST API root; tests point it at a local server
+var githubAPIURL = "https://api.github.com"
+
+func releaseCachePath(cfg *config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.Path), releaseCacheFileName)
+}
+
+func loadReleaseCache(cfg *config.Config) *releaseCache {
+	cache := &releaseCache{Entries: map[string]cachedRelease{}}
+	data, err := os.ReadFile(releaseCachePath(cfg))
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		fmt.Printf("Warning: ignoring unreadable release cache: %v\n", err)
+		return &releaseCache{Entries: map[string]cachedRelease{}}
+	}
+	if cache.Entries == nil {
+		cache.Entries = map[string]cachedRelease{}
+	}
+	return cache
+}
+
+func (c *releaseCache) save(cfg *config.Config) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return
+	}
+	path := releaseCachePath(cfg)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		fmt.Printf("Warning: failed to write release cache: %v\n", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+	}
+}
+
+// githubToken returns the API token from config or the environment, if any
+func githubToken(cfg *config.Config) string {
+	if cfg.GitHubToken != "" {
+		return cfg.GitHubToken
+	}
+	return os.Getenv("SENTINELGO_GITHUB_TOKEN")
+}
+
+func fetchLatestRelease(ctx context.Context, cfg *config.Config) (*GitHubRelease, error) {
+	return fetchRelease(ctx, cfg, fmt.Sprintf("%s/repos/%s/%s/releases/latest", githubAPIURL, cfg.GitHubOwner, cfg.GitHubRepo))
+}
+
+// fetchReleaseByTag fetches the release published under an exact tag
+func fetchReleaseByTag(ctx context.Context, cfg *config.Config, tag string) (*GitHubRelease, error) {
+	return fetchRelease(ctx, cfg, fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", githubAPIURL, cfg.GitHubOwner, cfg.GitHubRepo, url.PathEscape(tag)))
+}
+
+// fetchRelease performs a conditional GET against the releases API. A 304
+// reuses the cached release; while the rate limit is exhausted no request is
+// made and the cached release (if any) is returned instead.
+func fetchRelease(ctx context.Context, cfg *config.Config, apiURL string) (*GitHubRelease, error) {
+	releaseCacheMu.Lock()
+	defer releaseCacheMu.Unlock()
+
+	cache := loadReleaseCache(cfg)
+	cached, haveCached := cache.Entries[apiURL]
+
+	if time.Now().Before(cache.RateLimitReset) {
+		if haveCached {
+			fmt.Printf("GitHub rate limit exhausted until %s, using cached release %s\n", cache.RateLimitReset.Local().Format(time.RFC3339), cached.Release.TagName)
+			return &cached.Release, nil
+		}
+		return nil, fmt.Errorf("GitHub rate limit exhausted until %s", cache.RateLimitReset.Local().Format(time.RFC3339))
+	}
+
+	fmt.Printf("Fetching release from: %s\n", apiURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if token := githubToken(cfg); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if haveCached && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	reset, limited := rateLimitReset(resp)
+	if limited {
+		cache.RateLimitReset = reset
+		cache.save(cfg)
+		fmt.Printf("GitHub rate limit reached, backing off until %s\n", reset.Local().Format(time.RFC3339))
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// Fresh release, decoded below
+	case http.StatusNotModified:
+		if haveCached {
+			fmt.Printf("Release unchanged (ETag %s): %s\n", cached.ETag, cached.Release.TagName)
+			return &cached.Release, nil
+		}
+		return nil, fmt.Errorf("GitHub API returned 304 without a cached release")
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if limited && haveCached {
+			return &cached.Release, nil
+		}
+		return nil, fmt.Errorf("GitHub API status %d", resp.StatusCode)
+	default:
+		return nil, fmt.Errorf("GitHub API status %d", resp.StatusCode)
+	}
+
+	var rel GitHubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return nil, err
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		cache.Entries[apiURL] = cachedRelease{ETag: etag, Release: rel, FetchedAt: time.Now().UTC()}
+		cache.save(cfg)
+	}
+
+	fmt.Printf("Fetched release: %s with %d assets\n", rel.TagName, len(rel.Assets))
+	return &rel, nil
+}
+
+// rateLimitReset inspects the X-RateLimit-* and Retry-After headers and
+// reports whether further requests must wait, and until when.
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	if retry := resp.Header.Get("Retry-After"); retry != "" {
+		if secs, err := strconv.Atoi(retry); err == nil {
+			return time.Now().Add(time.Duration(secs) * time.Second), true
+		}
+	}
+
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining != "0" {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return time.Now().Add(defaultRateLimitBackoff), true
+		}
+		return time.Time{}, false
+	}
+
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(reset, 0), true
+	}
+	return time.Now().Add(defaultRateLimitBackoff), true
+}
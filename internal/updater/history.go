@@ -0,0 +1,168 @@
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sentinelgo/internal/config"
+)
+
+// Update history event types
+const (
+	EventCheck    = "check"
+	EventDownload = "download"
+	EventInstall  = "install"
+	EventRestart  = "restart"
+	EventRollback = "rollback"
+	EventVeto     = "veto"    // A pre-update hook refused the update
+	EventHealthy  = "healthy" // The new version started and sent a heartbeat
+)
+
+const (
+	historyFileName = "update-history.jsonl"
+	// historyMaxBytes triggers trimming the ledger down to historyKeepEntries
+	historyMaxBytes    = 1 << 20
+	historyKeepEntries = 1000
+)
+
+// HistoryEntry is one line of the update ledger
+type HistoryEntry struct {
+	Time        time.Time `json:"time"`
+	Event       string    `json:"event"`
+	FromVersion string    `json:"from_version,omitempty"`
+	ToVersion   string    `json:"to_version,omitempty"`
+	Asset       string    `json:"asset,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Success     bool      `json:"success"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// historyPath returns the ledger location, kept next to the config file
+func historyPath(cfg *config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.Path), historyFileName)
+}
+
+// recordHistory appends an entry to the ledger. Failures are logged, never
+// returned, so bookkeeping cannot block an update.
+func recordHistory(cfg *config.Config, entry HistoryEntry, err error) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	entry.Success = err == nil
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	line, mErr := json.Marshal(entry)
+	if mErr != nil {
+		fmt.Printf("Warning: failed to encode update history entry: %v\n", mErr)
+		return
+	}
+
+	path := historyPath(cfg)
+	f, oErr := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if oErr != nil {
+		fmt.Printf("Warning: failed to open update history: %v\n", oErr)
+		return
+	}
+	_, wErr := f.Write(append(line, '\n'))
+	f.Close()
+	if wErr != nil {
+		fmt.Printf("Warning: failed to write update history: %v\n", wErr)
+		return
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size() > historyMaxBytes {
+		trimHistory(cfg)
+	}
+}
+
+// trimHistory rewrites the ledger keeping only the newest entries
+func trimHistory(cfg *config.Config) {
+	entries, err := ReadHistory(cfg)
+	if err != nil || len(entries) <= historyKeepEntries {
+		return
+	}
+	entries = entries[len(entries)-historyKeepEntries:]
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	path := historyPath(cfg)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+	}
+}
+
+// ReadHistory returns every ledger entry, oldest first. A missing ledger is
+// not an error. Lines that fail to parse are skipped.
+func ReadHistory(cfg *config.Config) ([]HistoryEntry, error) {
+	f, err := os.Open(historyPath(cfg))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// LastResult returns the most recent ledger entry that records an update
+// action (anything but a plain check), or nil if there is none.
+func LastResult(cfg *config.Config) *HistoryEntry {
+	entries, err := ReadHistory(cfg)
+	if err != nil {
+		return nil
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Event != EventCheck {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// fileSHA256 returns the hex SHA-256 of a file
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
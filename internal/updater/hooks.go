@@ -0,0 +1,138 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"sentinelgo/internal/config"
+)
+
+// Hook phases, exposed to hook commands as SENTINELGO_HOOK_PHASE
+const (
+	hookPhasePre  = "pre-update"
+	hookPhasePost = "post-update"
+)
+
+const (
+	pendingUpdateFileName = "update-pending.json"
+	// defaultHookTimeout applies when hook_timeout is unset or invalid
+	defaultHookTimeout = 5 * time.Minute
+)
+
+// pendingUpdate is written just before restarting into a new version, so the
+// new process knows to run the post-update hook once it is healthy
+type pendingUpdate struct {
+	FromVersion string    `json:"from_version"`
+	ToVersion   string    `json:"to_version"`
+	Time        time.Time `json:"time"`
+}
+
+// runHook runs a hook command through the platform shell with a timeout.
+// The command sees the phase and the from/to versions in its environment.
+func runHook(ctx context.Context, cfg *config.Config, phase, command, from, to string) error {
+	timeout := cfg.GetHookTimeout()
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"SENTINELGO_HOOK_PHASE="+phase,
+		"SENTINELGO_FROM_VERSION="+from,
+		"SENTINELGO_TO_VERSION="+to,
+		"SENTINELGO_CONFIG="+cfg.Path,
+	)
+
+	fmt.Printf("Running %s hook: %s\n", phase, command)
+	output, err := cmd.CombinedOutput()
+	if out := strings.TrimSpace(string(output)); out != "" {
+		fmt.Printf("%s hook output:\n%s\n", phase, out)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s hook timed out after %v", phase, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook failed: %w", phase, err)
+	}
+	return nil
+}
+
+// runPreUpdateHook runs the configured pre-update hook. A non-zero exit status
+// or a timeout vetoes the update; the veto is recorded in the history.
+func runPreUpdateHook(ctx context.Context, cfg *config.Config, to string) error {
+	if cfg.PreUpdateHook == "" {
+		return nil
+	}
+	if err := runHook(ctx, cfg, hookPhasePre, cfg.PreUpdateHook, cfg.CurrentVersion, to); err != nil {
+		recordHistory(cfg, HistoryEntry{Event: EventVeto, FromVersion: cfg.CurrentVersion, ToVersion: to}, err)
+		return fmt.Errorf("update vetoed: %w", err)
+	}
+	return nil
+}
+
+func pendingUpdatePath(cfg *config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.Path), pendingUpdateFileName)
+}
+
+// markPendingUpdate records that a restart into version to is about to happen
+func markPendingUpdate(cfg *config.Config, from, to string) {
+	data, err := json.Marshal(pendingUpdate{FromVersion: from, ToVersion: to, Time: time.Now().UTC()})
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(pendingUpdatePath(cfg), data, 0644); err != nil {
+		fmt.Printf("Warning: failed to record pending update: %v\n", err)
+	}
+}
+
+// ConfirmHealthy is called by the agent once it has proven itself healthy
+// (its first heartbeat succeeded). If it was started by an update it records
+// the outcome and runs the post-update hook.
+func ConfirmHealthy(ctx context.Context, cfg *config.Config) {
+	path := pendingUpdatePath(cfg)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Not started by an update; release files staged by one are stale
+		discardAuxiliaryFiles(cfg)
+		return
+	}
+	os.Remove(path)
+
+	var pending pendingUpdate
+	if err := json.Unmarshal(data, &pending); err != nil {
+		fmt.Printf("Warning: ignoring unreadable pending update marker: %v\n", err)
+		return
+	}
+
+	entry := HistoryEntry{Event: EventHealthy, FromVersion: pending.FromVersion, ToVersion: pending.ToVersion}
+	if config.Version != "dev" && config.Version != pending.ToVersion {
+		discardAuxiliaryFiles(cfg)
+		recordHistory(cfg, entry, fmt.Errorf("running %s after update to %s", config.Version, pending.ToVersion))
+		return
+	}
+	recordHistory(cfg, entry, nil)
+	if err := commitAuxiliaryFiles(cfg); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	fmt.Printf("Update %s -> %s confirmed healthy\n", pending.FromVersion, pending.ToVersion)
+
+	if cfg.PostUpdateHook != "" {
+		if err := runHook(ctx, cfg, hookPhasePost, cfg.PostUpdateHook, pending.FromVersion, pending.ToVersion); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+}
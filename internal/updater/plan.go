@@ -0,0 +1,117 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"sentinelgo/internal/config"
+)
+
+// UpdatePlan describes what CheckAndApply would do, without doing it
+type UpdatePlan struct {
+	CurrentVersion  string `json:"current_version"`
+	LatestVersion   string `json:"latest_version"`
+	TargetVersion   string `json:"target_version"`
+	PinnedVersion   string `json:"pinned_version,omitempty"`
+	UpdateAvailable bool   `json:"update_available"`
+	Rollback        bool   `json:"rollback"`
+	AssetName       string `json:"asset_name,omitempty"`
+	AssetURL        string `json:"asset_url,omitempty"`
+	AssetSize       int64  `json:"asset_size,omitempty"`
+	AssetSHA256     string `json:"asset_sha256,omitempty"`
+	Mode            Mode   `json:"mode"`
+	Allowed         bool   `json:"allowed"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+// resolveTarget returns the release the updater should converge to: the
+// pinned tag when one is configured, otherwise the latest release.
+func resolveTarget(ctx context.Context, cfg *config.Config) (*GitHubRelease, error) {
+	if cfg.PinnedVersion != "" {
+		return fetchReleaseByTag(ctx, cfg, cfg.PinnedVersion)
+	}
+	return fetchLatestRelease(ctx, cfg)
+}
+
+// Plan resolves the target release and asset exactly as CheckAndApply does,
+// but never stops processes, downloads assets or installs anything.
+func Plan(ctx context.Context, cfg *config.Config) (*UpdatePlan, error) {
+	plan := &UpdatePlan{
+		CurrentVersion: cfg.CurrentVersion,
+		PinnedVersion:  cfg.PinnedVersion,
+		Mode:           EffectiveMode(cfg),
+	}
+
+	latest, err := fetchLatestRelease(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("fetch latest release: %w", err)
+	}
+	plan.LatestVersion = latest.TagName
+
+	target := latest
+	if cfg.PinnedVersion != "" && cfg.PinnedVersion != latest.TagName {
+		target, err = fetchReleaseByTag(ctx, cfg, cfg.PinnedVersion)
+		if err != nil {
+			return nil, fmt.Errorf("fetch pinned release %s: %w", cfg.PinnedVersion, err)
+		}
+	}
+	plan.TargetVersion = target.TagName
+
+	if target.TagName == cfg.CurrentVersion {
+		plan.Reason = "already at target version"
+		return plan, nil
+	}
+	plan.UpdateAvailable = true
+	plan.Rollback = compareVersions(target.TagName, cfg.CurrentVersion) < 0
+
+	asset, err := selectAsset(target, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		plan.Reason = err.Error()
+		return plan, nil
+	}
+	plan.AssetName = asset.Name
+	plan.AssetURL = asset.URL
+	plan.AssetSize = asset.Size
+	plan.AssetSHA256 = strings.TrimPrefix(asset.Digest, "sha256:")
+
+	if plan.Mode != ModeAuto {
+		plan.Reason = fmt.Sprintf("update_mode is %q", plan.Mode)
+		return plan, nil
+	}
+	plan.Allowed = true
+	return plan, nil
+}
+
+// compareVersions compares two "vMAJOR.MINOR.PATCH" style tags numerically,
+// returning -1, 0 or 1. Non-numeric parts fall back to string comparison.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var sa, sb string
+		if i < len(pa) {
+			sa = pa[i]
+		}
+		if i < len(pb) {
+			sb = pb[i]
+		}
+		na, errA := strconv.Atoi(sa)
+		nb, errB := strconv.Atoi(sb)
+		if errA == nil && errB == nil {
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if c := strings.Compare(sa, sb); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,124 @@
+package updater
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"sentinelgo/internal/config"
+
+	"github.com/kardianos/service"
+)
+
+// ExitCodeRestart is the exit status used to ask the service manager for a
+// restart when it cannot be asked directly. The systemd unit installed by
+// -install uses Restart=always, so any non-zero status brings the new binary up.
+const ExitCodeRestart = 75
+
+// runningUnderSystemd reports whether this process was started by systemd as
+// part of a service unit rather than from a shell.
+func runningUnderSystemd() bool {
+	if service.ChosenSystem() == nil || service.ChosenSystem().String() != "linux-systemd" {
+		return false
+	}
+	// systemd sets INVOCATION_ID for every unit it starts; terminals running
+	// inside a user session can inherit it too, hence the parent check.
+	return os.Getenv("INVOCATION_ID") != "" && os.Getppid() == 1
+}
+
+// systemdUnitName returns the unit this process belongs to, read from its
+// cgroup path, falling back to the name -install registers.
+func systemdUnitName() string {
+	f, err := os.Open("/proc/self/cgroup")
+	if err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			parts := strings.Split(scanner.Text(), "/")
+			for i := len(parts) - 1; i >= 0; i-- {
+				if strings.HasSuffix(parts[i], ".service") {
+					return parts[i]
+				}
+			}
+		}
+	}
+	return config.ServiceName + ".service"
+}
+
+// restartSystemdUnit asks systemd to restart the unit running this process.
+// --no-block is required: a blocking restart would wait on a job that stops
+// the very process issuing it. If systemctl is unavailable the process exits
+// with ExitCodeRestart and relies on the unit's Restart= policy.
+func restartSystemdUnit() error {
+	unit := systemdUnitName()
+	fmt.Printf("Restarting systemd unit %s\n", unit)
+
+	cmd := exec.Command("systemctl", "--no-block", "restart", unit)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		fmt.Printf("systemctl restart failed: %v (%s)\n", err, strings.TrimSpace(string(output)))
+		fmt.Printf("Exiting with status %d so systemd restarts the unit\n", ExitCodeRestart)
+		os.Exit(ExitCodeRestart)
+	}
+	return nil
+}
+
+// reexec replaces the current console-mode process with selfPath, keeping the
+// original arguments (-config, -run, ...) and environment. The PID is kept,
+// so nothing else is left running.
+func reexec(selfPath string) error {
+	fmt.Printf("Re-executing %s %s\n", selfPath, strings.Join(os.Args[1:], " "))
+	argv := append([]string{selfPath}, os.Args[1:]...)
+	if err := syscall.Exec(selfPath, argv, os.Environ()); err != nil {
+		return fmt.Errorf("exec new binary: %w", err)
+	}
+	return nil
+}
+
+// takeoverArgs returns this process's arguments (-config, ...) for a directly
+// started replacement, adding -run and -takeover unless they are already set
+func takeoverArgs() []string {
+	args := append([]string{}, os.Args[1:]...)
+	for _, flag := range []string{"run", "takeover"} {
+		set := false
+		for _, arg := range args {
+			name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if strings.HasPrefix(arg, "-") && name == flag {
+				set = true
+				break
+			}
+		}
+		if !set {
+			args = append(args, "-"+flag)
+		}
+	}
+	return args
+}
+
+// windowsRestartScript returns a batch script that swaps newPath into selfPath.
+// As a service it stops and starts the service around the swap; in console
+// mode it waits for this process to exit and relaunches with the same arguments.
+func windowsRestartScript(newPath, selfPath, bat string, asService bool) string {
+	if asService {
+		return fmt.Sprintf(`@echo off
+
+net stop "%s"
+timeout /t 2 /nobreak >nul
+move /Y "%s" "%s"
+net start "%s"
+del "%s"`, config.ServiceName, newPath, selfPath, config.ServiceName, bat)
+	}
+
+	var args []string
+	for _, arg := range os.Args[1:] {
+		args = append(args, `"`+arg+`"`)
+	}
+	return fmt.Sprintf(`@echo off
+
+timeout /t 2 /nobreak >nul
+move /Y "%s" "%s"
+start "" "%s" %s
+del "%s"`, newPath, selfPath, selfPath, strings.Join(args, " "), bat)
+}
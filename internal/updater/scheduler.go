@@ -0,0 +1,159 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"sentinelgo/internal/config"
+)
+
+// Mode controls how far the scheduler takes an available update
+type Mode string
+
+const (
+	ModeOff      Mode = "off"      // Never contact GitHub
+	ModeNotify   Mode = "notify"   // Check and record available updates only
+	ModeDownload Mode = "download" // Also download the asset, but never install it
+	ModeAuto     Mode = "auto"     // Download, install and restart
+)
+
+// defaultCheckInterval is used when update_check_interval is unset or invalid
+const defaultCheckInterval = 6 * time.Hour
+
+// EffectiveMode returns the configured update mode. Configs predating
+// update_mode fall back to the auto_update flag, so the safe default (off)
+// is kept for them.
+func EffectiveMode(cfg *config.Config) Mode {
+	switch Mode(cfg.UpdateMode) {
+	case ModeOff, ModeNotify, ModeDownload, ModeAuto:
+		return Mode(cfg.UpdateMode)
+	case "":
+		if cfg.AutoUpdate {
+			return ModeAuto
+		}
+		return ModeOff
+	default:
+		fmt.Printf("Warning: unknown update_mode %q, treating as %q\n", cfg.UpdateMode, ModeOff)
+		return ModeOff
+	}
+}
+
+// Scheduler is the single owner of periodic update checks
+type Scheduler struct {
+	cfg      *config.Config
+	mode     Mode
+	interval time.Duration
+}
+
+// NewScheduler creates a scheduler from the update settings in cfg
+func NewScheduler(cfg *config.Config) *Scheduler {
+	interval := cfg.GetUpdateCheckInterval()
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	return &Scheduler{
+		cfg:      cfg,
+		mode:     EffectiveMode(cfg),
+		interval: interval,
+	}
+}
+
+// Mode returns the mode the scheduler runs in
+func (s *Scheduler) Mode() Mode {
+	return s.mode
+}
+
+// Run performs an update pass after a random delay within the first interval,
+// then once per jittered interval, until ctx is cancelled. In ModeOff it
+// returns immediately without any network access.
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.mode == ModeOff {
+		fmt.Println("Automatic updates are disabled (update_mode: off)")
+		return
+	}
+	fmt.Printf("Update scheduler running in %q mode every %v\n", s.mode, s.interval)
+
+	// Random first delay spreads checks across the whole interval
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(s.interval))))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if err := s.RunOnce(ctx); err != nil {
+				fmt.Printf("Update pass failed: %v\n", err)
+			}
+			timer.Reset(jitter(s.interval))
+		}
+	}
+}
+
+// RunOnce performs a single update pass according to the scheduler's mode
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	switch s.mode {
+	case ModeNotify:
+		return notifyUpdate(ctx, s.cfg)
+	case ModeDownload:
+		return prefetchUpdate(ctx, s.cfg)
+	case ModeAuto:
+		return CheckAndApply(ctx, s.cfg)
+	default:
+		return nil
+	}
+}
+
+// notifyUpdate reports an available update without downloading it
+func notifyUpdate(ctx context.Context, cfg *config.Config) error {
+	latest, asset, err := checkForUpdate(ctx, cfg)
+	if err != nil || latest == nil {
+		return err
+	}
+	fmt.Printf("Update available: %s -> %s (%s); not applying in %q mode\n", cfg.CurrentVersion, latest.TagName, asset.Name, ModeNotify)
+	return nil
+}
+
+// prefetchUpdate downloads the update into its resumable part file, so a
+// later install (after switching to auto mode) needs no network transfer.
+func prefetchUpdate(ctx context.Context, cfg *config.Config) error {
+	latest, asset, err := checkForUpdate(ctx, cfg)
+	if err != nil || latest == nil {
+		return err
+	}
+
+	selfPath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	d := download{
+		URL:         asset.URL,
+		Part:        partPath(selfPath, asset, latest.TagName),
+		Size:        asset.Size,
+		BytesPerSec: cfg.UpdateBandwidthLimit,
+	}
+	entry := HistoryEntry{Event: EventDownload, FromVersion: cfg.CurrentVersion, ToVersion: latest.TagName, Asset: asset.Name}
+
+	fmt.Printf("Downloading update %s without installing (%q mode)\n", latest.TagName, ModeDownload)
+	if err := fetchPart(ctx, d); err != nil {
+		recordHistory(cfg, entry, err)
+		return err
+	}
+	removeOtherParts(selfPath, d.Part)
+	entry.SHA256, err = fileSHA256(d.Part)
+	recordHistory(cfg, entry, err)
+	return err
+}
+
+// jitter returns d shifted by a random amount of up to ±10%, so a fleet
+// started at the same moment does not hit the GitHub API in lockstep
+func jitter(d time.Duration) time.Duration {
+	spread := int64(d) / 5
+	if spread <= 0 {
+		return d
+	}
+	return d - time.Duration(spread/2) + time.Duration(rand.Int63n(spread))
+}
@@ -0,0 +1,93 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"sentinelgo/internal/config"
+)
+
+// fakeGitHub serves a release for every request and counts the requests
+func fakeGitHub(t *testing.T) *atomic.Int64 {
+	t.Helper()
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		json.NewEncoder(w).Encode(GitHubRelease{TagName: "v9.9.9"})
+	}))
+	t.Cleanup(srv.Close)
+
+	prev := githubAPIURL
+	githubAPIURL = srv.URL
+	t.Cleanup(func() { githubAPIURL = prev })
+	return &requests
+}
+
+func testConfig(t *testing.T) *config.Config {
+	return &config.Config{
+		Path:                filepath.Join(t.TempDir(), "config.json"),
+		GitHubOwner:         "owner",
+		GitHubRepo:          "repo",
+		CurrentVersion:      "v1.0.0",
+		UpdateCheckInterval: "10ms",
+	}
+}
+
+func TestDisabledSchedulerMakesNoRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		updateMode string
+		autoUpdate bool
+	}{
+		{name: "update_mode off", updateMode: "off", autoUpdate: true},
+		{name: "auto_update false fallback", updateMode: "", autoUpdate: false},
+		{name: "unknown update_mode", updateMode: "sometimes", autoUpdate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := fakeGitHub(t)
+			cfg := testConfig(t)
+			cfg.UpdateMode = tt.updateMode
+			cfg.AutoUpdate = tt.autoUpdate
+
+			s := NewScheduler(cfg)
+			if s.Mode() != ModeOff {
+				t.Fatalf("Mode() = %q, want %q", s.Mode(), ModeOff)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			s.Run(ctx)
+			if err := s.RunOnce(ctx); err != nil {
+				t.Fatalf("RunOnce: %v", err)
+			}
+			<-ctx.Done()
+
+			if n := requests.Load(); n != 0 {
+				t.Fatalf("disabled scheduler made %d request(s)", n)
+			}
+		})
+	}
+}
+
+// TestNotifySchedulerMakesRequests checks that the fake server does see
+// requests when checks are enabled, so the test above cannot pass vacuously
+func TestNotifySchedulerMakesRequests(t *testing.T) {
+	requests := fakeGitHub(t)
+	cfg := testConfig(t)
+	cfg.UpdateMode = string(ModeNotify)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	NewScheduler(cfg).Run(ctx)
+
+	if requests.Load() == 0 {
+		t.Fatal("notify scheduler made no requests")
+	}
+}
@@ -2,18 +2,17 @@ package updater
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
 	"time"
 
 	"sentinelgo/internal/config"
+
+	"github.com/kardianos/service"
 )
 
 type GitHubRelease struct {
@@ -24,262 +23,103 @@ type GitHubRelease struct {
 type Asset struct {
 	Name string `json:"name"`
 	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+	// Digest is the "sha256:<hex>" checksum GitHub publishes for the asset
+	Digest string `json:"digest"`
 }
 
-// ProcessInfo contains information about a running SentinelGo process
-type ProcessInfo struct {
-	PID     int
-	Version string
-	CmdLine string
-}
-
-func CheckAndApply(ctx context.Context, cfg *config.Config) error {
-	latest, err := fetchLatestRelease(ctx, cfg)
+// checkForUpdate resolves the target release and the asset for this platform.
+// It returns a nil release when the device is already at the target version.
+func checkForUpdate(ctx context.Context, cfg *config.Config) (*GitHubRelease, *Asset, error) {
+	latest, err := resolveTarget(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("fetch latest release: %w", err)
+		recordHistory(cfg, HistoryEntry{Event: EventCheck, FromVersion: cfg.CurrentVersion}, err)
+		return nil, nil, fmt.Errorf("fetch target release: %w", err)
 	}
+	recordHistory(cfg, HistoryEntry{Event: EventCheck, FromVersion: cfg.CurrentVersion, ToVersion: latest.TagName}, nil)
 
 	if latest.TagName == cfg.CurrentVersion {
 		fmt.Printf("Already up to date: %s\n", latest.TagName)
-		return nil // already up-to-date
+		return nil, nil, nil
 	}
 
-	assetURL, err := selectAsset(latest, runtime.GOOS, runtime.GOARCH)
+	asset, err := selectAsset(latest, runtime.GOOS, runtime.GOARCH)
 	if err != nil {
-		return fmt.Errorf("select asset: %w", err)
+		return nil, nil, fmt.Errorf("select asset: %w", err)
+	}
+	return latest, asset, nil
+}
+
+func CheckAndApply(ctx context.Context, cfg *config.Config) error {
+	latest, asset, err := checkForUpdate(ctx, cfg)
+	if err != nil || latest == nil {
+		return err
+	}
+
+	// Converging to an older pinned version is recorded as a rollback
+	installEvent := EventInstall
+	if compareVersions(latest.TagName, cfg.CurrentVersion) < 0 {
+		fmt.Printf("Rolling back to pinned version %s\n", latest.TagName)
+		installEvent = EventRollback
 	}
 
 	fmt.Printf("Found update: %s -> %s\n", cfg.CurrentVersion, latest.TagName)
 
-	// Stop all old processes before applying update
-	fmt.Println("Stopping old SentinelGo processes before update...")
-	if err := stopOldProcesses(); err != nil {
-		fmt.Printf("Warning: Failed to stop some old processes: %v\n", err)
+	// The pre-update hook may drain work or veto the update entirely
+	if err := runPreUpdateHook(ctx, cfg, latest.TagName); err != nil {
+		return err
 	}
 
-	// Give processes more time to fully stop
-	fmt.Println("Waiting for old processes to fully terminate...")
-	time.Sleep(5 * time.Second)
+	// Other agent instances cannot be running: the machine-wide lock is held
+	// by this process, and the restarted binary takes it over through the
+	// lock handoff protocol rather than by killing processes.
 
-	// Double-check no old processes remain
-	processes, _ := findOldProcesses()
-	if len(processes) > 0 {
-		fmt.Printf("Warning: %d old process(es) still running, proceeding anyway...\n", len(processes))
-		for _, proc := range processes {
-			fmt.Printf("  PID: %d, Version: %s\n", proc.PID, proc.Version)
-		}
-		// Force kill remaining processes
-		fmt.Println("Force killing remaining old processes...")
-		for _, proc := range processes {
-			var cmd *exec.Cmd
-			switch runtime.GOOS {
-			case "windows":
-				cmd = exec.Command("taskkill", "/F", "/PID", strconv.Itoa(proc.PID))
-			case "linux", "darwin":
-				cmd = exec.Command("kill", "-KILL", strconv.Itoa(proc.PID))
-			}
-			if err := cmd.Run(); err != nil {
-				// Log error but continue - process might already be dead
-				fmt.Printf("Warning: failed to kill process %d: %v\n", proc.PID, err)
-			}
+	// Release files left by an earlier attempt must not be committed by this one.
+	discardAuxiliaryFiles(cfg)
+
+	// Prefer a small binary patch against the running executable; any problem
+	// with it falls back to downloading the full asset.
+	newPath, sum, ok := tryDeltaUpdate(ctx, cfg, latest)
+	assetName := patchAssetName(runtime.GOOS, runtime.GOARCH, cfg.CurrentVersion, latest.TagName)
+	if !ok {
+		newPath, sum, err = downloadAndReplace(ctx, cfg, asset, latest.TagName)
+		if err != nil {
+			return fmt.Errorf("download and replace: %w", err)
 		}
-		// Wait for force kill to take effect
-		time.Sleep(2 * time.Second)
-	} else {
-		fmt.Println("All old processes stopped successfully")
+		assetName = asset.Name
 	}
 
-	newPath, err := downloadAndReplace(ctx, assetURL, latest.TagName)
-	if err != nil {
-		return fmt.Errorf("download and replace: %w", err)
-	}
+	entry := HistoryEntry{Event: installEvent, FromVersion: cfg.CurrentVersion, ToVersion: latest.TagName, Asset: assetName, SHA256: sum}
 
 	// Verify binary replacement was successful
 	if _, err := os.Stat(newPath); os.IsNotExist(err) {
-		return fmt.Errorf("new binary not found after replacement: %w", err)
+		err = fmt.Errorf("new binary not found after replacement: %w", err)
+		recordHistory(cfg, entry, err)
+		return err
 	}
 
 	fmt.Printf("Successfully updated to version %s\n", latest.TagName)
 
 	// Update config version
+	fromVersion := cfg.CurrentVersion
 	cfg.CurrentVersion = latest.TagName
 	if err := cfg.Save(); err != nil {
+		recordHistory(cfg, entry, err)
 		return fmt.Errorf("save config: %w", err)
 	}
+	recordHistory(cfg, entry, nil)
 
-	// Restart using new binary
-	return restart(newPath)
-}
-
-// findOldProcesses finds all running SentinelGo processes except the current one
-func findOldProcesses() ([]ProcessInfo, error) {
-	var cmd *exec.Cmd
-
-	switch runtime.GOOS {
-	case "windows":
-		cmd = exec.Command("tasklist", "/fi", "imagename eq sentinelgo.exe", "/fo", "csv", "/v")
-	case "linux", "darwin":
-		cmd = exec.Command("ps", "aux")
-	default:
-		return nil, fmt.Errorf("unsupported OS: %s", runtime.GOOS)
-	}
-
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
-	}
-
-	return parseProcessOutput(string(output)), nil
-}
-
-// parseProcessOutput parses the output of process listing commands
-func parseProcessOutput(output string) []ProcessInfo {
-	var processes []ProcessInfo
-	lines := strings.Split(output, "\n")
-
-	currentPID := os.Getpid()
-	currentVersion := getCurrentVersion()
-
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		var info ProcessInfo
-
-		switch runtime.GOOS {
-		case "windows":
-			if strings.Contains(line, "sentinelgo.exe") {
-				fields := strings.Split(line, ",")
-				if len(fields) >= 5 {
-					pid, _ := strconv.Atoi(strings.Trim(fields[1], `"`))
-					if pid != currentPID { // Skip current process
-						info.PID = pid
-						info.CmdLine = strings.Trim(fields[8], `"`)
-						info.Version = getProcessVersion(info.CmdLine, pid)
-						// Only include if it's a different version or unknown
-						if info.Version != currentVersion {
-							processes = append(processes, info)
-						}
-					}
-				}
-			}
-		case "linux", "darwin":
-			if strings.Contains(line, "sentinelgo") && !strings.Contains(line, "grep") && !strings.Contains(line, "systemctl") && !strings.Contains(line, "journalctl") && !strings.Contains(line, "editor") {
-				fields := strings.Fields(line)
-				if len(fields) >= 2 {
-					pid, _ := strconv.Atoi(fields[1])
-					if pid != currentPID { // Skip current process
-						info.PID = pid
-						info.CmdLine = strings.Join(fields[10:], " ")
-						info.Version = getProcessVersion(info.CmdLine, pid)
-						// Only include if it's a different version or unknown
-						if info.Version != currentVersion {
-							processes = append(processes, info)
-						}
-					}
-				}
-			}
-		}
-	}
-
-	return processes
-}
-
-// getProcessVersion determines the version of a running process
-func getProcessVersion(cmdLine string, pid int) string {
-	// Try to extract version from command line first
-	if version := extractVersionFromCmd(cmdLine); version != "unknown" {
-		return version
-	}
-
-	// Try to get version from binary
-	if version := getBinaryVersion(cmdLine); version != "unknown" {
-		return version
-	}
-
-	// Try to get version from executable path
-	if version := extractVersionFromPath(cmdLine); version != "unknown" {
-		return version
-	}
-
-	return "unknown"
-}
-
-// extractVersionFromCmd tries to extract version from command line arguments
-func extractVersionFromCmd(cmdLine string) string {
-	if strings.Contains(cmdLine, "-version=") {
-		parts := strings.Split(cmdLine, "-version=")
-		if len(parts) > 1 {
-			version := strings.Split(parts[1], " ")[0]
-			return strings.Trim(version, `"`)
-		}
-	}
-
-	// Look for version flag as separate argument
-	if strings.Contains(cmdLine, "-version") || strings.Contains(cmdLine, "--version") {
-		// Try to find version after the flag
-		parts := strings.Fields(cmdLine)
-		for i, part := range parts {
-			if (part == "-version" || part == "--version") && i+1 < len(parts) {
-				return strings.Trim(parts[i+1], `"`)
-			}
-		}
-	}
-
-	return "unknown"
-}
-
-// getBinaryVersion tries to get version from the binary executable
-func getBinaryVersion(cmdLine string) string {
-	// Extract binary path from command line
-	var binaryPath string
-	parts := strings.Fields(cmdLine)
-
-	if len(parts) > 0 {
-		binaryPath = parts[0]
-		// Handle relative paths
-		if !strings.Contains(binaryPath, "/") && runtime.GOOS != "windows" {
-			// Try to find binary in PATH
-			if path, err := exec.LookPath(binaryPath); err == nil {
-				binaryPath = path
-			}
-		}
-	}
-
-	// Try to get version by running binary with -version flag
-	if binaryPath != "" {
-		cmd := exec.Command(binaryPath, "-version")
-		output, err := cmd.Output()
-		if err == nil {
-			outputStr := string(output)
-			// Parse version output
-			lines := strings.Split(outputStr, "\n")
-			for _, line := range lines {
-				if strings.Contains(line, "version:") || strings.Contains(line, "version") {
-					// Extract version from line like "SentinelGo version: v1.0.0"
-					parts := strings.Fields(line)
-					for i, part := range parts {
-						if strings.Contains(part, "version") && i+1 < len(parts) {
-							return strings.Trim(parts[i+1], ",")
-						}
-					}
-				}
-			}
-		}
-	}
-
-	return "unknown"
-}
-
-// getCurrentVersion returns the current version of the running process
-func getCurrentVersion() string {
-	// Try to get version from config or use build version
-	cfg, err := config.Load("")
-	if err == nil && cfg.CurrentVersion != "" {
-		return cfg.CurrentVersion
+	// Restart using new binary. The entry is written first because a
+	// successful restart may replace or end this process.
+	entry.Event = EventRestart
+	recordHistory(cfg, entry, nil)
+	markPendingUpdate(cfg, fromVersion, latest.TagName)
+	if err := restart(newPath); err != nil {
+		discardAuxiliaryFiles(cfg)
+		recordHistory(cfg, entry, err)
+		return err
 	}
-	return config.Version
+	return nil
 }
 
 // extractVersionFromPath extracts version from executable path
@@ -294,66 +134,6 @@ func extractVersionFromPath(path string) string {
 	return "unknown"
 }
 
-// stopOldProcesses stops all running SentinelGo processes except the current one
-func stopOldProcesses() error {
-	processes, err := findOldProcesses()
-	if err != nil {
-		return err
-	}
-
-	if len(processes) == 0 {
-		fmt.Println("No old SentinelGo processes found")
-		return nil
-	}
-
-	fmt.Printf("Found %d old SentinelGo process(es) to stop:\n", len(processes))
-	for _, proc := range processes {
-		fmt.Printf("  PID: %d, Version: %s\n", proc.PID, proc.Version)
-	}
-
-	fmt.Println("Stopping old processes...")
-	for _, proc := range processes {
-		var cmd *exec.Cmd
-		switch runtime.GOOS {
-		case "windows":
-			cmd = exec.Command("taskkill", "/F", "/PID", strconv.Itoa(proc.PID))
-		case "linux", "darwin":
-			cmd = exec.Command("kill", "-TERM", strconv.Itoa(proc.PID))
-		}
-
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Failed to stop PID %d: %v\n", proc.PID, err)
-		} else {
-			fmt.Printf("Stopped PID %d\n", proc.PID)
-		}
-	}
-
-	// Wait a moment for processes to stop
-	time.Sleep(3 * time.Second)
-
-	// Check if any processes are still running
-	remaining, _ := findOldProcesses()
-	if len(remaining) > 0 {
-		fmt.Printf("Force killing %d remaining process(es)...\n", len(remaining))
-		for _, proc := range remaining {
-			var cmd *exec.Cmd
-			switch runtime.GOOS {
-			case "windows":
-				cmd = exec.Command("taskkill", "/F", "/PID", strconv.Itoa(proc.PID))
-			case "linux", "darwin":
-				cmd = exec.Command("kill", "-KILL", strconv.Itoa(proc.PID))
-			}
-			if err := cmd.Run(); err != nil {
-				fmt.Printf("Warning: failed to force kill PID %d: %v\n", proc.PID, err)
-			}
-		}
-		// Wait for force kill to take effect
-		time.Sleep(2 * time.Second)
-	}
-
-	return nil
-}
-
 // stopLaunchdService stops the launchd service on macOS
 func stopLaunchdService() error {
 	if runtime.GOOS != "darwin" {
@@ -428,48 +208,29 @@ func startLaunchdService() error {
 	return nil
 }
 
-func fetchLatestRelease(ctx context.Context, cfg *config.Config) (*GitHubRelease, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", cfg.GitHubOwner, cfg.GitHubRepo)
-	fmt.Printf("Fetching release from: %s\n", url)
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API status %d", resp.StatusCode)
+// selectAsset picks the release asset for goos/goarch. Archives are preferred
+// because they also carry auxiliary files; a bare binary is used otherwise.
+func selectAsset(rel *GitHubRelease, goos, goarch string) (*Asset, error) {
+	switch goos {
+	case "windows", "linux", "darwin":
+	default:
+		return nil, fmt.Errorf("unsupported OS %s", goos)
 	}
 
-	var rel GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
-		return nil, err
+	platform := fmt.Sprintf("%s-%s", goos, goarch)
+	patterns := []string{
+		fmt.Sprintf("sentinelgo-%s.tar.gz", platform),
+		fmt.Sprintf("sentinelgo-%s.zip", platform),
+		fmt.Sprintf("sentinelgo-%s-%s.tar.gz", rel.TagName, platform),
+		fmt.Sprintf("sentinelgo-%s-%s.zip", rel.TagName, platform),
 	}
-
-	fmt.Printf("Fetched release: %s with %d assets\n", rel.TagName, len(rel.Assets))
-	return &rel, nil
-}
-
-func selectAsset(rel *GitHubRelease, goos, goarch string) (string, error) {
-	var suffix string
-	switch goos {
-	case "windows":
-		suffix = ".exe"
-	case "linux", "darwin":
-		suffix = ""
-	default:
-		return "", fmt.Errorf("unsupported OS %s", goos)
+	if goos == "windows" && goarch == "amd64" {
+		// Older release workflows name the Windows archive without the arch
+		patterns = append(patterns, fmt.Sprintf("sentinelgo-%s-windows.tar.gz", rel.TagName))
 	}
+	patterns = append(patterns, binaryAssetName(goos, goarch))
 
-	pattern := fmt.Sprintf("sentinelgo-%s-%s%s", goos, goarch, suffix)
-	fmt.Printf("Looking for asset: %s\n", pattern)
+	fmt.Printf("Looking for assets: %v\n", patterns)
 	fmt.Printf("Available assets: %v\n", func() (names []string) {
 		for _, asset := range rel.Assets {
 			names = append(names, asset.Name)
@@ -477,47 +238,125 @@ func selectAsset(rel *GitHubRelease, goos, goarch string) (string, error) {
 		return
 	}())
 
-	for _, asset := range rel.Assets {
-		if asset.Name == pattern {
-			fmt.Printf("Found matching asset: %s\n", asset.Name)
-			return asset.URL, nil
+	for _, pattern := range patterns {
+		for i := range rel.Assets {
+			if rel.Assets[i].Name == pattern {
+				fmt.Printf("Found matching asset: %s\n", rel.Assets[i].Name)
+				return &rel.Assets[i], nil
+			}
 		}
 	}
-	return "", fmt.Errorf("no matching asset for %s-%s", goos, goarch)
+	return nil, fmt.Errorf("no matching asset for %s", platform)
 }
 
-func downloadAndReplace(ctx context.Context, url, version string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// downloadAndReplace downloads the asset and stages the new binary next to the
+// running executable as "<exe>.new". Archive assets are extracted first and
+// their allowlisted auxiliary files are staged for the release files
+// directory. It returns the
+// staged path and the SHA-256 of the downloaded asset.
+func downloadAndReplace(ctx context.Context, cfg *config.Config, asset *Asset, version string) (string, string, error) {
+	selfPath, err := os.Executable()
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", err
+	newPath := selfPath + ".new"
+	entry := HistoryEntry{FromVersion: cfg.CurrentVersion, ToVersion: version, Asset: asset.Name}
+
+	kind := archiveKind(asset.Name)
+
+	// Preflight: the download plus, for archives, its extracted contents must fit
+	need := asset.Size
+	if kind != archiveNone {
+		need = asset.Size * 3
+	}
+	if err := checkFreeSpace(filepath.Dir(selfPath), need); err != nil {
+		entry.Event = EventDownload
+		recordHistory(cfg, entry, err)
+		return "", "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download failed status %d", resp.StatusCode)
+	d := download{
+		URL:         asset.URL,
+		Part:        partPath(selfPath, asset, version),
+		Size:        asset.Size,
+		BytesPerSec: cfg.UpdateBandwidthLimit,
 	}
 
-	selfPath, err := os.Executable()
+	if kind == archiveNone {
+		d.Dest, d.Perm = newPath, 0755
+		sum, err := fetchAsset(ctx, cfg, d, entry)
+		if err != nil {
+			os.Remove(newPath)
+			return "", "", err
+		}
+		return newPath, sum, nil
+	}
+
+	archivePath := selfPath + ".download"
+	stagingDir := selfPath + ".staging"
+	defer os.Remove(archivePath)
+	defer os.RemoveAll(stagingDir)
+
+	d.Dest, d.Perm = archivePath, 0644
+	sum, err := fetchAsset(ctx, cfg, d, entry)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
+	entry.SHA256 = sum
+	entry.Event = EventInstall
 
-	newPath := selfPath + ".new"
-	f, err := os.OpenFile(newPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	fmt.Printf("Extracting %s (%s)\n", asset.Name, kind)
+	files, err := extractArchive(archivePath, kind, stagingDir)
 	if err != nil {
-		return "", err
+		err = fmt.Errorf("extract archive: %w", err)
+		recordHistory(cfg, entry, err)
+		return "", "", err
 	}
-	defer f.Close()
 
-	if _, err := io.Copy(f, resp.Body); err != nil {
-		return "", err
+	var binary *stagedFile
+	for i := range files {
+		if files[i].Binary {
+			binary = &files[i]
+		}
+	}
+	if err := os.Chmod(binary.Staged, 0755); err != nil {
+		err = fmt.Errorf("chmod new binary: %w", err)
+		recordHistory(cfg, entry, err)
+		return "", "", err
+	}
+	if err := os.Rename(binary.Staged, newPath); err != nil {
+		err = fmt.Errorf("stage new binary: %w", err)
+		recordHistory(cfg, entry, err)
+		return "", "", err
+	}
+
+	// Auxiliary files wait in a pending directory until the new version is
+	// healthy, so a failed restart leaves the installed set untouched
+	if err := stageAuxiliaryFiles(cfg, files); err != nil {
+		os.Remove(newPath)
+		err = fmt.Errorf("stage auxiliary files for %s: %w", version, err)
+		recordHistory(cfg, entry, err)
+		return "", "", err
 	}
 
-	return newPath, nil
+	return newPath, sum, nil
+}
+
+// fetchAsset downloads d and records the outcome in the update history
+func fetchAsset(ctx context.Context, cfg *config.Config, d download, entry HistoryEntry) (string, error) {
+	entry.Event = EventDownload
+	if err := downloadFile(ctx, d); err != nil {
+		recordHistory(cfg, entry, err)
+		return "", err
+	}
+	sum, err := fileSHA256(d.Dest)
+	if err != nil {
+		recordHistory(cfg, entry, err)
+		return "", err
+	}
+	entry.SHA256 = sum
+	recordHistory(cfg, entry, nil)
+	return sum, nil
 }
 
 func restart(newPath string) error {
@@ -555,8 +394,9 @@ func restart(newPath string) error {
 		if err := startLaunchdService(); err != nil {
 			fmt.Printf("Warning: Failed to start launchd service: %v\n", err)
 			fmt.Println("Falling back to direct execution...")
-			// Fallback to direct execution
-			cmd := exec.Command(selfPath, "-run")
+			// Fallback to direct execution with the original arguments; the
+			// new process asks this one to drain and hand over the instance lock
+			cmd := exec.Command(selfPath, takeoverArgs()...)
 			if err := cmd.Start(); err != nil {
 				return fmt.Errorf("failed to start fallback execution: %w", err)
 			}
@@ -564,82 +404,39 @@ func restart(newPath string) error {
 			return nil
 		}
 
-		// Final verification - ensure only new version is running
-		time.Sleep(3 * time.Second)
-		fmt.Println("Verifying only new version is running...")
-
-		// Check for any remaining old processes
-		finalCheck, _ := findOldProcesses()
-		if len(finalCheck) > 0 {
-			fmt.Printf("Warning: Found %d old process(es) still running after update:\n", len(finalCheck))
-			for _, proc := range finalCheck {
-				fmt.Printf("  PID: %d, Version: %s\n", proc.PID, proc.Version)
-			}
-			fmt.Println("Force stopping remaining old processes...")
-			for _, proc := range finalCheck {
-				var cmd *exec.Cmd
-				switch runtime.GOOS {
-				case "windows":
-					cmd = exec.Command("taskkill", "/F", "/PID", strconv.Itoa(proc.PID))
-				case "linux", "darwin":
-					cmd = exec.Command("kill", "-KILL", strconv.Itoa(proc.PID))
-				}
-				if err := cmd.Run(); err != nil {
-					fmt.Printf("Warning: failed to force stop PID %d: %v\n", proc.PID, err)
-				}
-			}
-			time.Sleep(1 * time.Second)
-		} else {
-			fmt.Println("Success: Only new version is running")
-		}
-
-		return nil
+		// The launchd job retries (KeepAlive) until it can take the instance
+		// lock, which is released when this process exits
+		fmt.Println("Exiting so the launchd service can take over")
+		os.Exit(0)
 	}
 
-	// For Linux and Windows
-	if runtime.GOOS != "windows" {
-		// Replace current binary with new one
-		if err := os.Rename(newPath, selfPath); err != nil {
-			return err
-		}
-		// Wait before starting new process
-		time.Sleep(2 * time.Second)
-		cmd := exec.Command(selfPath)
-		return cmd.Start()
-	} else {
-		// Windows: use batch script to replace after exit
+	if runtime.GOOS == "windows" {
+		// Windows cannot replace a running executable, so a batch script swaps
+		// the binary once the service (or this console process) has stopped
+		asService := !service.Interactive()
 		bat := selfPath + ".bat"
-		script := fmt.Sprintf(`@echo off
-
-timeout /t 2 /nobreak >nul
-move /Y "%s" "%s"
-"%s"
-del "%s"`, newPath, selfPath, selfPath, bat)
+		script := windowsRestartScript(newPath, selfPath, bat, asService)
 		if err := os.WriteFile(bat, []byte(script), 0644); err != nil {
 			return err
 		}
-		cmd := exec.Command(bat)
-		return cmd.Start()
+		cmd := exec.Command("cmd", "/C", bat)
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		if !asService {
+			fmt.Println("Exiting so the new binary can be moved into place")
+			os.Exit(0)
+		}
+		return nil
 	}
-}
 
-// AutoUpdateChecker runs automatic updates in background
-func AutoUpdateChecker(ctx context.Context, cfg *config.Config) {
-	ticker := time.NewTicker(1 * time.Hour) // Check every hour
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			fmt.Println("Checking for updates...")
-
-			if err := CheckAndApply(ctx, cfg); err != nil {
-				fmt.Printf("Auto-update failed: %v\n", err)
-			} else {
-				fmt.Println("Auto-update completed successfully")
-			}
-		}
+	// Linux: replace the binary in place, then restart through the service
+	// manager when running as a unit, or re-exec when running in a console
+	if err := os.Rename(newPath, selfPath); err != nil {
+		return err
+	}
+	if runningUnderSystemd() {
+		return restartSystemdUnit()
 	}
+	return reexec(selfPath)
 }